@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rohanparmar/go-user-api/internal/auth"
+	"github.com/rohanparmar/go-user-api/internal/service"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+)
+
+const apiKeyUsage = `usage: server apikey <command>
+
+commands:
+  mint NAME --scopes SCOPES (--tenant TENANT | --all-tenants) [--ttl DURATION]
+                                               create a key; SCOPES is a comma separated
+                                               list of users:read, users:write, users:admin;
+                                               the key can only act for TENANT, or with
+                                               --all-tenants for any tenant it names
+  list                                         show all keys (never the secrets)
+  rotate ID [--overlap DURATION]               replace a key, keeping the old one valid for
+                                               the overlap (default 24h)
+  revoke ID                                    disable a key immediately`
+
+// runAPIKey implements the "apikey" subcommand of the server binary
+func runAPIKey(ctx context.Context, keys service.APIKeyService, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command\n%s", apiKeyUsage)
+	}
+
+	switch command, rest := args[0], args[1:]; command {
+	case "mint":
+		if len(rest) == 0 || strings.HasPrefix(rest[0], "-") {
+			return fmt.Errorf("mint needs a name\n%s", apiKeyUsage)
+		}
+		fs := newAPIKeyFlagSet("mint")
+		scopeList := fs.String("scopes", "", "")
+		ttl := fs.Duration("ttl", 0, "")
+		tenantID := fs.String("tenant", "", "")
+		allTenants := fs.Bool("all-tenants", false, "")
+		if err := fs.Parse(rest[1:]); err != nil {
+			return fmt.Errorf("%v\n%s", err, apiKeyUsage)
+		}
+		// Acting for any tenant must be asked for explicitly
+		switch {
+		case *allTenants && *tenantID != "":
+			return fmt.Errorf("--tenant and --all-tenants cannot be combined\n%s", apiKeyUsage)
+		case *allTenants:
+			*tenantID = tenant.All
+		case *tenantID == "":
+			return fmt.Errorf("mint needs --tenant or --all-tenants\n%s", apiKeyUsage)
+		}
+		scopes, err := auth.ParseScopes(*scopeList)
+		if err != nil {
+			return err
+		}
+		minted, err := keys.Mint(ctx, rest[0], *tenantID, scopes, *ttl)
+		if err != nil {
+			return err
+		}
+		printMintedAPIKey(out, minted)
+
+	case "list":
+		records, err := keys.List(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tTENANT\tPREFIX\tSCOPES\tCREATED\tEXPIRES\tREVOKED")
+		for _, key := range records {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				key.ID, key.Name, key.TenantID, key.Prefix, strings.Join(key.Scopes, ","),
+				formatKeyTime(key.CreatedAt), formatKeyTime(key.ExpiresAt), formatKeyTime(key.RevokedAt),
+			)
+		}
+		w.Flush()
+
+	case "rotate":
+		id, err := parseAPIKeyID(rest)
+		if err != nil {
+			return err
+		}
+		fs := newAPIKeyFlagSet("rotate")
+		overlap := fs.Duration("overlap", 24*time.Hour, "")
+		if err := fs.Parse(rest[1:]); err != nil {
+			return fmt.Errorf("%v\n%s", err, apiKeyUsage)
+		}
+		minted, err := keys.Rotate(ctx, id, *overlap)
+		if err != nil {
+			return err
+		}
+		printMintedAPIKey(out, minted)
+		fmt.Fprintf(out, "key %d stays valid until %s\n", id, time.Now().Add(*overlap).UTC().Format(time.RFC3339))
+
+	case "revoke":
+		id, err := parseAPIKeyID(rest)
+		if err != nil {
+			return err
+		}
+		if err := keys.Revoke(ctx, id); err != nil {
+			if errors.Is(err, service.ErrNotFound) {
+				return fmt.Errorf("no active key with ID %d", id)
+			}
+			return err
+		}
+		fmt.Fprintf(out, "revoked key %d\n", id)
+
+	default:
+		return fmt.Errorf("unknown command %q\n%s", command, apiKeyUsage)
+	}
+	return nil
+}
+
+func newAPIKeyFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+func parseAPIKeyID(args []string) (int32, error) {
+	if len(args) == 0 {
+		return 0, fmt.Errorf("missing key ID\n%s", apiKeyUsage)
+	}
+	id, err := strconv.ParseInt(args[0], 10, 32)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid key ID %q\n%s", args[0], apiKeyUsage)
+	}
+	return int32(id), nil
+}
+
+func printMintedAPIKey(out io.Writer, minted service.MintedAPIKey) {
+	fmt.Fprintf(out, "id:      %d\n", minted.Record.ID)
+	fmt.Fprintf(out, "name:    %s\n", minted.Record.Name)
+	fmt.Fprintf(out, "tenant:  %s\n", minted.Record.TenantID)
+	fmt.Fprintf(out, "scopes:  %s\n", strings.Join(minted.Record.Scopes, ","))
+	fmt.Fprintf(out, "expires: %s\n", formatKeyTime(minted.Record.ExpiresAt))
+	fmt.Fprintf(out, "key:     %s\n\nStore the key now, it cannot be shown again.\n", minted.Key)
+}
+
+func formatKeyTime(t pgtype.Timestamptz) string {
+	if !t.Valid {
+		return "-"
+	}
+	return t.Time.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/rohanparmar/go-user-api/config"
+)
+
+const configUsage = `usage: server [flags] config <command>
+
+commands:
+  print   show the effective configuration as YAML, with secrets redacted`
+
+// runConfig implements the "config" subcommand of the server binary
+func runConfig(cfg *config.Config, args []string, out io.Writer) error {
+	if len(args) != 1 || args[0] != "print" {
+		return fmt.Errorf("unknown config command\n%s", configUsage)
+	}
+	return config.Print(out, cfg)
+}
@@ -2,90 +2,292 @@
 Package main is the entry point of the Go User API application.
 
 It is responsible for:
-1. Loading configuration from environment variables.
-2. Initializing the structured logger (Zap).
+1. Loading configuration from a file, environment variables and flags.
+2. Initializing the structured logger (Zap) and OpenTelemetry tracing.
 3. Establishing a connection to the PostgreSQL database.
 4. setting up the dependency injection container (Repository -> Service -> Handler).
-5. Configuring the GoFiber HTTP server and middleware.
-6. Registering API routes and starting the server.
+5. Applying database migrations, when MIGRATE_ON_START is set.
+6. Configuring the GoFiber HTTP server and middleware.
+7. Registering API routes and starting the server.
+8. Shutting down gracefully on SIGINT/SIGTERM: readiness fails first, then
+   in-flight requests are drained and background jobs stopped before the
+   database pool is closed.
+
+Running "server migrate <command>" manages the schema, "server apikey <command>"
+mints and revokes API keys and "server config print" shows the effective
+configuration, instead of starting the server (see migrate.go, apikey.go and
+config.go).
 */
 package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/rohanparmar/go-user-api/config"
-	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/rohanparmar/go-user-api/db/migrations"
+	"github.com/rohanparmar/go-user-api/internal/auth"
 	"github.com/rohanparmar/go-user-api/internal/handler"
+	"github.com/rohanparmar/go-user-api/internal/health"
+	"github.com/rohanparmar/go-user-api/internal/jobs"
 	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/rohanparmar/go-user-api/internal/metrics"
 	"github.com/rohanparmar/go-user-api/internal/middleware"
+	"github.com/rohanparmar/go-user-api/internal/migrate"
 	"github.com/rohanparmar/go-user-api/internal/repository"
 	"github.com/rohanparmar/go-user-api/internal/routes"
 	"github.com/rohanparmar/go-user-api/internal/service"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rohanparmar/go-user-api/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"go.uber.org/zap"
 )
 
 func main() {
-	// Load config
-	cfg := config.LoadConfig()
+	// Load config from file, env and flags; what is left is the command
+	cfg, args, err := config.Load(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
+	if len(args) > 0 && args[0] == "config" {
+		if err := runConfig(cfg, args[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
 	// Initialize logger
-	env := cfg.GetEnv("ENV", "development")
-	if err := logger.InitLogger(env); err != nil {
+	if err := logger.InitLogger(cfg.Server.Env, cfg.Logging.Level); err != nil {
 		log.Fatal("Failed to initialize logger:", err)
 	}
-	defer logger.Sync()
 
+	// run returns its errors rather than exiting, so its deferred cleanup runs
+	if err := run(cfg, args); err != nil {
+		logger.Log.Error("Fatal error", zap.Error(err))
+		logger.Sync()
+		os.Exit(1)
+	}
+	logger.Sync()
+}
+
+// run serves the API, or runs the command given in args, until it is done
+func run(cfg *config.Config, args []string) error {
 	logger.Log.Info("Starting Go User API server...")
 
-	// Connect to PostgreSQL
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s",
-		cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName,
-	)
-	
-	pool, err := pgxpool.New(context.Background(), dsn)
+	// Set up tracing
+	flushTraces, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		StdoutFile:  cfg.Tracing.StdoutFile,
+	})
 	if err != nil {
-		logger.Log.Fatal("Failed to connect to database", zap.Error(err))
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := flushTraces(ctx); err != nil {
+			logger.Log.Warn("Failed to flush traces", zap.Error(err))
+		}
+	}()
+
+	// Connect to PostgreSQL, waiting for it to come up if needed
+	poolConfig, err := repository.NewPoolConfig(cfg.DB)
+	if err != nil {
+		return fmt.Errorf("invalid database configuration: %w", err)
+	}
+	poolConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+	if cfg.Tenancy.RowLevelSecurity {
+		repository.EnableRowLevelSecurity(poolConfig)
+	}
+
+	pool, err := repository.NewPostgresPool(context.Background(), poolConfig, cfg.DB.ConnectRetryTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 	defer pool.Close()
 
 	logger.Log.Info("Database connection established successfully")
 
-	// Initialize SQLC queries
-	queries := db.New(pool)
+	// Apply migrations embedded in the binary
+	migrator, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrate(context.Background(), migrator, args[1:], os.Stdout); err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+		return nil
+	}
+
+	if len(args) > 0 && args[0] == "apikey" {
+		keys := service.NewAPIKeyService(repository.NewAPIKeyRepository(pool))
+		if err := runAPIKey(context.Background(), keys, args[1:], os.Stdout); err != nil {
+			return fmt.Errorf("API key command failed: %w", err)
+		}
+		return nil
+	}
+
+	if len(args) > 0 {
+		return fmt.Errorf("unknown command %q", strings.Join(args, " "))
+	}
+
+	if cfg.DB.MigrateOnStart {
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to apply migrations: %w", err)
+		}
+		logger.Log.Info("Database schema is up to date", zap.Int("applied", applied))
+	}
+
+	// Metrics registry, served on /metrics
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		metrics.NewPoolCollector(pool),
+	)
+	appMetrics := metrics.New(registry)
 
 	// Initialize layers (Repository -> Service -> Handler)
-	userRepo := repository.NewUserRepository(queries)
-	userService := service.NewUserService(userRepo)
-	userHandler := handler.NewUserHandler(userService)
+	userRepo := repository.NewUserRepository(pool)
+	if cfg.Security.CursorSecret == "" {
+		logger.Log.Warn("CURSOR_SECRET is not set, list cursors will not survive restarts")
+	}
+	policy, err := auth.NewPolicy(cfg.RBAC.Roles)
+	if err != nil {
+		return fmt.Errorf("invalid access policy: %w", err)
+	}
+	userService := service.NewTracedUserService(service.NewUserService(userRepo,
+		service.WithPolicy(policy),
+		service.WithCursorSecret([]byte(cfg.Security.CursorSecret)),
+		service.WithBatchLimit(cfg.Features.BatchMaxOperations),
+		service.WithMetrics(appMetrics),
+		service.WithQuotas(cfg.Tenancy.MaxUsers, cfg.Tenancy.Quotas),
+	))
+	userHandler := handler.NewUserHandler(userService,
+		handler.WithImportLimit(cfg.Features.ImportMaxBytes),
+	)
+	idempotencyRepo := repository.NewIdempotencyRepository(pool)
+	apiKeyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(pool))
+
+	// Bearer tokens are accepted when a JWKS is configured
+	var tokenVerifier *auth.JWTVerifier
+	if cfg.JWT.Enabled() {
+		keySet := auth.NewFileKeySet(cfg.JWT.JWKSFile, cfg.JWT.RefreshInterval)
+		if cfg.JWT.JWKSURL != "" {
+			keySet = auth.NewURLKeySet(cfg.JWT.JWKSURL, &http.Client{Timeout: 5 * time.Second}, cfg.JWT.RefreshInterval)
+		}
+		if err := keySet.Load(context.Background()); err != nil {
+			return fmt.Errorf("failed to load JWKS: %w", err)
+		}
+		tokenVerifier = auth.NewJWTVerifier(keySet, cfg.JWT.Issuer, cfg.JWT.Audience, cfg.JWT.Leeway)
+	}
+
+	// Start background jobs; they are stopped and waited for before the pool
+	// they use is closed
+	var jobsRunning sync.WaitGroup
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer func() {
+		stopJobs()
+		jobsRunning.Wait()
+	}()
+
+	if cfg.Features.PurgeInterval > 0 {
+		jobsRunning.Add(2)
+		go func() {
+			defer jobsRunning.Done()
+			jobs.PurgeDeletedUsers(jobsCtx, userService, cfg.Features.PurgeInterval, cfg.Features.PurgeRetention)
+		}()
+		go func() {
+			defer jobsRunning.Done()
+			jobs.PurgeIdempotencyKeys(jobsCtx, idempotencyRepo, cfg.Features.PurgeInterval)
+		}()
+	}
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			logger.Log.Error("Request error", zap.Error(err))
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Internal server error",
-			})
-		},
+		ErrorHandler: handler.ErrorHandler,
+		// Lets POST /users/import read files larger than the body limit, which
+		// BodyLimit enforces for every other route
+		StreamRequestBody: true,
 	})
 
 	// Middleware
+	app.Use(middleware.BodyLimit(fiber.DefaultBodyLimit, "/users/import"))
 	app.Use(middleware.RequestID())
+	app.Use(middleware.Tracing())
+	app.Use(middleware.Metrics(appMetrics))
 	app.Use(middleware.RequestDuration())
+	app.Use(middleware.Authenticate(apiKeyService, tokenVerifier))
+	app.Use(middleware.Tenant(cfg.Tenancy.Header, cfg.Tenancy.DefaultTenant))
+	app.Use(middleware.AuditContext())
 
 	// Setup routes
-	routes.SetupRoutes(app, userHandler)
+	var readiness health.Readiness
+	healthHandler := handler.NewHealthHandler(&readiness,
+		health.DatabaseCheck(pool),
+		health.MigrationCheck(migrator),
+	)
+	routes.SetupRoutes(app, userHandler, healthHandler, metrics.Handler(registry), middleware.Idempotency(idempotencyRepo, cfg.Features.IdempotencyTTL, cfg.Features.IdempotencyLockTimeout), middleware.RequireScope)
 
 	// Start server
-	port := cfg.GetEnv("PORT", "8080")
+	port := strconv.Itoa(cfg.Server.Port)
 	logger.Log.Info("Server starting", zap.String("port", port))
-	
-	if err := app.Listen(":" + port); err != nil {
-		logger.Log.Fatal("Failed to start server", zap.Error(err))
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- app.Listen(":" + port)
+	}()
+
+	// Wait for a termination signal or for the server to fail
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("failed to start server: %w", err)
+	case <-signalCtx.Done():
+	}
+	stopSignals() // a second signal kills the process right away
+
+	shutdown(app, &readiness, cfg.Server.ShutdownDelay, cfg.Server.ShutdownTimeout)
+
+	// Deferred: stop and wait for jobs, close the pool, then flush traces
+	return nil
+}
+
+// shutdown fails readiness, waits delay for load balancers to notice, then
+// stops accepting connections and waits up to timeout for in-flight requests
+func shutdown(app *fiber.App, readiness *health.Readiness, delay, timeout time.Duration) {
+	logger.Log.Info("Shutting down, failing readiness", zap.Duration("delay", delay))
+	readiness.Drain()
+	time.Sleep(delay)
+
+	logger.Log.Info("Draining in-flight requests", zap.Duration("timeout", timeout))
+	if err := app.ShutdownWithTimeout(timeout); err != nil {
+		logger.Log.Warn("Shutdown deadline exceeded, dropping open connections", zap.Error(err))
+		return
 	}
+	logger.Log.Info("Server stopped")
 }
 
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/rohanparmar/go-user-api/internal/migrate"
+)
+
+const migrateUsage = `usage: server migrate <command>
+
+commands:
+  up              apply all pending migrations
+  down [N|all]    revert the last N migrations (default 1)
+  status          show the database version and pending migrations
+  force VERSION   record VERSION as applied and clear the dirty flag (-1 for none)`
+
+// runMigrate implements the "migrate" subcommand of the server binary
+func runMigrate(ctx context.Context, migrator *migrate.Migrator, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command\n%s", migrateUsage)
+	}
+
+	switch command, rest := args[0], args[1:]; command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "applied %d migration(s)\n", applied)
+
+	case "down":
+		steps := 1
+		if len(rest) > 0 {
+			if rest[0] == "all" {
+				steps = 0
+			} else if n, err := strconv.Atoi(rest[0]); err == nil && n > 0 {
+				steps = n
+			} else {
+				return fmt.Errorf("invalid number of steps %q\n%s", rest[0], migrateUsage)
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "reverted %d migration(s)\n", reverted)
+
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printMigrationStatus(out, status)
+
+	case "force":
+		if len(rest) != 1 {
+			return fmt.Errorf("force needs a version\n%s", migrateUsage)
+		}
+		version, err := strconv.ParseInt(rest[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q\n%s", rest[0], migrateUsage)
+		}
+		if err := migrator.Force(ctx, version); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "forced version %d\n", version)
+
+	default:
+		return fmt.Errorf("unknown command %q\n%s", command, migrateUsage)
+	}
+	return nil
+}
+
+func printMigrationStatus(out io.Writer, status migrate.Status) {
+	dirty := ""
+	if status.Dirty {
+		dirty = " (dirty)"
+	}
+	fmt.Fprintf(out, "version: %d%s\n\n", status.Version, dirty)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+	for _, m := range status.Migrations {
+		state := "pending"
+		if m.Applied {
+			state = "applied"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", m.Version, m.Name, state)
+	}
+	w.Flush()
+}
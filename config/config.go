@@ -1,50 +1,320 @@
 /*
 Package config handles the loading and management of application configuration.
-It uses the "godotenv" library to load environment variables from a .env file (for local development)
-and provides helper methods to access these variables with default fallback values.
+Settings are read, from lowest to highest precedence, from the defaults below,
+a YAML or TOML file, environment variables (a .env file is loaded first for
+local development) and command line flags. See load.go for the sources and
+Validate for the rules checked at startup.
 */
 package config
 
 import (
-	"log"
-	"os"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"reflect"
+	"slices"
+	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+	"go.uber.org/zap/zapcore"
 )
 
+// Environments accepted in Server.Env
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// redacted replaces secrets in printed configuration
+const redacted = "[REDACTED]"
+
+// Config is the complete application configuration. Each setting can come
+// from the file (yaml/toml key), the environment (env) or a flag (flag).
+// Fields tagged secret are redacted by Redacted.
 type Config struct {
-	DBHost     string
-	DBPort     string
-	DBUser     string
-	DBPassword string
-	DBName     string
+	Server   ServerConfig   `yaml:"server" toml:"server"`
+	DB       DBConfig       `yaml:"db" toml:"db"`
+	Logging  LoggingConfig  `yaml:"logging" toml:"logging"`
+	Security SecurityConfig `yaml:"security" toml:"security"`
+	Features FeaturesConfig `yaml:"features" toml:"features"`
+	Tracing  TracingConfig  `yaml:"tracing" toml:"tracing"`
+	JWT      JWTConfig      `yaml:"jwt" toml:"jwt"`
+	RBAC     RBACConfig     `yaml:"rbac" toml:"rbac"`
+	Tenancy  TenancyConfig  `yaml:"tenancy" toml:"tenancy"`
 }
 
-func LoadConfig() *Config {
-	// Load .env file
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, reading environment variables")
-	}
+type ServerConfig struct {
+	Port int    `yaml:"port" toml:"port" env:"PORT" flag:"port" usage:"HTTP port to listen on"`
+	Env  string `yaml:"env" toml:"env" env:"ENV" flag:"env" usage:"development or production"`
+
+	// On SIGTERM readiness fails for ShutdownDelay before the server stops
+	// accepting connections, then in-flight requests get ShutdownTimeout to finish
+	ShutdownDelay   time.Duration `yaml:"shutdown_delay" toml:"shutdown_delay" env:"SHUTDOWN_DELAY" flag:"shutdown-delay" usage:"time readiness fails before the listener closes"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"time in-flight requests get to finish"`
+}
+
+// DBConfig describes the database connection. URL, when set, replaces the
+// discrete connection fields (Host to Name); the TLS, timeout and pool
+// settings apply either way.
+type DBConfig struct {
+	URL      string `yaml:"url" toml:"url" env:"DATABASE_URL" secret:"true"`
+	Host     string `yaml:"host" toml:"host" env:"DB_HOST" flag:"db-host" usage:"database host"`
+	Port     int    `yaml:"port" toml:"port" env:"DB_PORT" flag:"db-port" usage:"database port"`
+	User     string `yaml:"user" toml:"user" env:"DB_USER" flag:"db-user" usage:"database user"`
+	Password string `yaml:"password" toml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name     string `yaml:"name" toml:"name" env:"DB_NAME" flag:"db-name" usage:"database name"`
+
+	// SSLMode is a libpq sslmode (disable, allow, prefer, require, verify-ca
+	// or verify-full); empty keeps the driver default, prefer
+	SSLMode     string `yaml:"sslmode" toml:"sslmode" env:"DB_SSLMODE" flag:"db-sslmode" usage:"disable, allow, prefer, require, verify-ca or verify-full"`
+	SSLRootCert string `yaml:"sslrootcert" toml:"sslrootcert" env:"DB_SSLROOTCERT" flag:"db-sslrootcert" usage:"CA certificate file used to verify the server"`
+	SSLCert     string `yaml:"sslcert" toml:"sslcert" env:"DB_SSLCERT" flag:"db-sslcert" usage:"client certificate file"`
+	SSLKey      string `yaml:"sslkey" toml:"sslkey" env:"DB_SSLKEY" flag:"db-sslkey" usage:"client private key file"`
+
+	MaxConns          int32         `yaml:"max_conns" toml:"max_conns" env:"DB_MAX_CONNS" flag:"db-max-conns" usage:"maximum pool size"`
+	MinConns          int32         `yaml:"min_conns" toml:"min_conns" env:"DB_MIN_CONNS" flag:"db-min-conns" usage:"connections kept open when idle"`
+	MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime" toml:"max_conn_lifetime" env:"DB_MAX_CONN_LIFETIME" flag:"db-max-conn-lifetime" usage:"age after which connections are replaced"`
+	MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time" toml:"max_conn_idle_time" env:"DB_MAX_CONN_IDLE_TIME" flag:"db-max-conn-idle-time" usage:"idle time after which connections are closed"`
+	HealthCheckPeriod time.Duration `yaml:"health_check_period" toml:"health_check_period" env:"DB_HEALTH_CHECK_PERIOD" flag:"db-health-check-period" usage:"how often idle connections are checked"`
+
+	// ConnectTimeout bounds each connection attempt; StatementTimeout is set
+	// as the server statement_timeout (0 disables it)
+	ConnectTimeout   time.Duration `yaml:"connect_timeout" toml:"connect_timeout" env:"DB_CONNECT_TIMEOUT" flag:"db-connect-timeout" usage:"timeout of a single connection attempt"`
+	StatementTimeout time.Duration `yaml:"statement_timeout" toml:"statement_timeout" env:"DB_STATEMENT_TIMEOUT" flag:"db-statement-timeout" usage:"maximum run time of a statement, 0 for none"`
+
+	// ConnectRetryTimeout is how long startup keeps retrying an unreachable
+	// database, with exponential backoff, before giving up
+	ConnectRetryTimeout time.Duration `yaml:"connect_retry_timeout" toml:"connect_retry_timeout" env:"DB_CONNECT_RETRY_TIMEOUT" flag:"db-connect-retry-timeout" usage:"how long to retry the database at startup"`
+
+	// MigrateOnStart applies pending migrations before the server starts
+	MigrateOnStart bool `yaml:"migrate_on_start" toml:"migrate_on_start" env:"MIGRATE_ON_START" flag:"migrate-on-start" usage:"apply pending migrations at startup"`
+}
+
+type LoggingConfig struct {
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL" flag:"log-level" usage:"debug, info, warn or error"`
+}
+
+type SecurityConfig struct {
+	// CursorSecret signs list pagination cursors; share it across instances
+	CursorSecret string `yaml:"cursor_secret" toml:"cursor_secret" env:"CURSOR_SECRET" secret:"true"`
+}
+
+type FeaturesConfig struct {
+	// BatchMaxOperations caps the number of operations in POST /users:batch
+	BatchMaxOperations int `yaml:"batch_max_operations" toml:"batch_max_operations" env:"BATCH_MAX_OPERATIONS" flag:"batch-max-operations" usage:"maximum operations per batch request"`
+
+	// ImportMaxBytes caps the size of a file uploaded to POST /users/import
+	ImportMaxBytes int64 `yaml:"import_max_bytes" toml:"import_max_bytes" env:"IMPORT_MAX_BYTES" flag:"import-max-bytes" usage:"maximum size of an import file in bytes"`
+
+	// IdempotencyTTL is how long an Idempotency-Key and its response are kept
+	IdempotencyTTL time.Duration `yaml:"idempotency_ttl" toml:"idempotency_ttl" env:"IDEMPOTENCY_TTL" flag:"idempotency-ttl" usage:"how long idempotency keys are kept"`
+
+	// IdempotencyLockTimeout is how long a request holds its Idempotency-Key
+	// before a retry may take over; it must exceed the longest request
+	IdempotencyLockTimeout time.Duration `yaml:"idempotency_lock_timeout" toml:"idempotency_lock_timeout" env:"IDEMPOTENCY_LOCK_TIMEOUT" flag:"idempotency-lock-timeout" usage:"time after which an unfinished idempotent request can be retried"`
+
+	// Soft-deleted users are purged once they are older than PurgeRetention.
+	// A zero PurgeInterval disables the purge jobs.
+	PurgeRetention time.Duration `yaml:"purge_retention" toml:"purge_retention" env:"PURGE_RETENTION" flag:"purge-retention" usage:"age at which soft-deleted users are purged"`
+	PurgeInterval  time.Duration `yaml:"purge_interval" toml:"purge_interval" env:"PURGE_INTERVAL" flag:"purge-interval" usage:"how often the purge jobs run, 0 to disable"`
+}
+
+type TracingConfig struct {
+	// Exporter is none, otlp or stdout. The OTLP endpoint is read from the
+	// standard OTEL_EXPORTER_OTLP_ENDPOINT variable.
+	Exporter    string  `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER" flag:"tracing-exporter" usage:"none, otlp or stdout"`
+	ServiceName string  `yaml:"service_name" toml:"service_name" env:"OTEL_SERVICE_NAME" flag:"tracing-service-name" usage:"service name reported on spans"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" flag:"tracing-sample-ratio" usage:"fraction of new traces recorded"`
+	StdoutFile  string  `yaml:"stdout_file" toml:"stdout_file" env:"TRACING_STDOUT_FILE" flag:"tracing-stdout-file" usage:"file the stdout exporter writes to"`
+}
+
+// JWTConfig enables bearer token authentication when a JWKS file or URL is
+// set. Tokens must be issued by Issuer for Audience.
+type JWTConfig struct {
+	JWKSFile string `yaml:"jwks_file" toml:"jwks_file" env:"JWT_JWKS_FILE" flag:"jwt-jwks-file" usage:"JWKS document with the token signing keys"`
+	JWKSURL  string `yaml:"jwks_url" toml:"jwks_url" env:"JWT_JWKS_URL" flag:"jwt-jwks-url" usage:"URL of the JWKS document with the token signing keys"`
+	Issuer   string `yaml:"issuer" toml:"issuer" env:"JWT_ISSUER" flag:"jwt-issuer" usage:"required iss claim"`
+	Audience string `yaml:"audience" toml:"audience" env:"JWT_AUDIENCE" flag:"jwt-audience" usage:"required aud claim"`
+
+	// RefreshInterval is how long the JWKS is cached before it is loaded again
+	RefreshInterval time.Duration `yaml:"refresh_interval" toml:"refresh_interval" env:"JWT_JWKS_REFRESH_INTERVAL" flag:"jwt-jwks-refresh-interval" usage:"how often the JWKS is reloaded"`
+	// Leeway allows for clock skew when checking exp and nbf
+	Leeway time.Duration `yaml:"leeway" toml:"leeway" env:"JWT_LEEWAY" flag:"jwt-leeway" usage:"clock skew allowed on exp and nbf"`
+}
+
+// Enabled reports whether bearer tokens are accepted
+func (c JWTConfig) Enabled() bool {
+	return c.JWKSFile != "" || c.JWKSURL != ""
+}
+
+// RBACConfig defines roles for the access policy, by name, on top of the
+// built-in ones (admin, support, user and one per API key scope). It can
+// only be set in the config file.
+type RBACConfig struct {
+	// Roles maps a role to its permissions, e.g. "user:read" or "user:read:own"
+	Roles map[string][]string `yaml:"roles" toml:"roles"`
+}
+
+// TenancyConfig controls how requests are assigned to tenants and how many
+// active users each tenant may have. Quotas can only be set in the config file.
+type TenancyConfig struct {
+	Header string `yaml:"header" toml:"header" env:"TENANT_HEADER" flag:"tenant-header" usage:"header naming the tenant of a request"`
+	// DefaultTenant serves requests that do not name a tenant; empty makes
+	// the header (or a tenant bound to the credentials) required
+	DefaultTenant string `yaml:"default_tenant" toml:"default_tenant" env:"DEFAULT_TENANT" flag:"default-tenant" usage:"tenant of requests that do not name one, empty to require one"`
+
+	// RowLevelSecurity sets app.tenant_id on every database connection for
+	// the tenant_isolation policies, which also need RLS enabled on the tables
+	RowLevelSecurity bool `yaml:"row_level_security" toml:"row_level_security" env:"TENANT_ROW_LEVEL_SECURITY" flag:"tenant-row-level-security" usage:"set app.tenant_id for the row-level security policies"`
 
-	return &Config{
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     getEnv("DB_PORT", "5432"),
-		DBUser:     getEnv("DB_USER", "postgres"),
-		DBPassword: getEnv("DB_PASSWORD", "rohan"),
-		DBName:     getEnv("DB_NAME", "go_user_api"),
+	// MaxUsers is the quota of active users of each tenant, 0 for none;
+	// Quotas overrides it by tenant ID
+	MaxUsers int            `yaml:"max_users" toml:"max_users" env:"TENANT_MAX_USERS" flag:"tenant-max-users" usage:"active users allowed per tenant, 0 for no limit"`
+	Quotas   map[string]int `yaml:"quotas" toml:"quotas"`
+}
+
+// Default returns the configuration used for settings no source provides.
+// There is deliberately no default database password or cursor secret.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:            8080,
+			Env:             EnvDevelopment,
+			ShutdownDelay:   5 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		DB: DBConfig{
+			Host: "localhost",
+			Port: 5432,
+			User: "postgres",
+			Name: "go_user_api",
+
+			MaxConns:          10,
+			MaxConnLifetime:   time.Hour,
+			MaxConnIdleTime:   30 * time.Minute,
+			HealthCheckPeriod: time.Minute,
+
+			ConnectTimeout:      5 * time.Second,
+			ConnectRetryTimeout: 30 * time.Second,
+		},
+		Logging: LoggingConfig{Level: "info"},
+		Features: FeaturesConfig{
+			BatchMaxOperations:     1000,
+			ImportMaxBytes:         1 << 30,
+			IdempotencyTTL:         24 * time.Hour,
+			IdempotencyLockTimeout: time.Minute,
+			PurgeRetention:         30 * 24 * time.Hour,
+			PurgeInterval:          time.Hour,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "go-user-api",
+			SampleRatio: 1,
+		},
+		JWT: JWTConfig{
+			RefreshInterval: 15 * time.Minute,
+			Leeway:          30 * time.Second,
+		},
+		Tenancy: TenancyConfig{
+			Header:        "X-Tenant-ID",
+			DefaultTenant: tenant.Default,
+		},
 	}
 }
 
-// GetEnv is a public method to read environment variables
-func (c *Config) GetEnv(key, fallback string) string {
-	return getEnv(key, fallback)
+// IsProduction reports whether the server runs in the production environment
+func (c *Config) IsProduction() bool {
+	return c.Server.Env == EnvProduction
 }
 
-// Helper to read env or use default
-func getEnv(key, fallback string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+// Validate checks the whole configuration and reports every problem at once
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port must be between 1 and 65535, got %d", c.Server.Port)
+	check(c.Server.Env == EnvDevelopment || c.Server.Env == EnvProduction, "server.env must be %s or %s, got %q", EnvDevelopment, EnvProduction, c.Server.Env)
+	check(c.Server.ShutdownDelay >= 0, "server.shutdown_delay cannot be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+
+	if c.DB.URL == "" {
+		check(c.DB.Host != "", "db.host is required")
+		check(c.DB.Port > 0 && c.DB.Port <= 65535, "db.port must be between 1 and 65535, got %d", c.DB.Port)
+		check(c.DB.User != "", "db.user is required")
+		check(c.DB.Name != "", "db.name is required")
 	}
-	return fallback
+	switch c.DB.SSLMode {
+	case "", "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		check(false, "db.sslmode must be disable, allow, prefer, require, verify-ca or verify-full, got %q", c.DB.SSLMode)
+	}
+	check((c.DB.SSLCert == "") == (c.DB.SSLKey == ""), "db.sslcert and db.sslkey must be set together")
+	check(c.DB.MaxConns > 0, "db.max_conns must be positive")
+	check(c.DB.MinConns >= 0 && c.DB.MinConns <= c.DB.MaxConns, "db.min_conns must be between 0 and db.max_conns")
+	check(c.DB.MaxConnLifetime >= 0 && c.DB.MaxConnIdleTime >= 0 && c.DB.HealthCheckPeriod >= 0,
+		"db.max_conn_lifetime, db.max_conn_idle_time and db.health_check_period cannot be negative")
+	check(c.DB.ConnectTimeout >= 0 && c.DB.StatementTimeout >= 0 && c.DB.ConnectRetryTimeout >= 0,
+		"db.connect_timeout, db.statement_timeout and db.connect_retry_timeout cannot be negative")
+
+	_, err := zapcore.ParseLevel(c.Logging.Level)
+	check(err == nil, "logging.level must be debug, info, warn or error, got %q", c.Logging.Level)
+
+	if c.IsProduction() {
+		check(c.DB.Password != "" || c.DB.URL != "", "db.password is required in production")
+		check(len(c.Security.CursorSecret) >= 32, "security.cursor_secret must be at least 32 characters in production")
+	}
+
+	check(c.Features.BatchMaxOperations > 0, "features.batch_max_operations must be positive")
+	check(c.Features.ImportMaxBytes > 0, "features.import_max_bytes must be positive")
+	check(c.Features.IdempotencyTTL > 0, "features.idempotency_ttl must be positive")
+	check(c.Features.IdempotencyLockTimeout > 0, "features.idempotency_lock_timeout must be positive")
+	check(c.Features.PurgeRetention >= 0, "features.purge_retention cannot be negative")
+	check(c.Features.PurgeInterval >= 0, "features.purge_interval cannot be negative")
+
+	switch c.Tracing.Exporter {
+	case "none", "otlp", "stdout":
+	default:
+		check(false, "tracing.exporter must be none, otlp or stdout, got %q", c.Tracing.Exporter)
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+
+	if c.JWT.Enabled() {
+		check(c.JWT.JWKSFile == "" || c.JWT.JWKSURL == "", "jwt.jwks_file and jwt.jwks_url cannot both be set")
+		if c.JWT.JWKSURL != "" {
+			u, err := url.Parse(c.JWT.JWKSURL)
+			check(err == nil && (u.Scheme == "https" || u.Scheme == "http" && !c.IsProduction()) && u.Host != "",
+				"jwt.jwks_url must be an https URL")
+		}
+		check(c.JWT.Issuer != "", "jwt.issuer is required with a JWKS")
+		check(c.JWT.Audience != "", "jwt.audience is required with a JWKS")
+		check(c.JWT.RefreshInterval > 0, "jwt.refresh_interval must be positive")
+		check(c.JWT.Leeway >= 0, "jwt.leeway cannot be negative")
+	}
+
+	check(c.Tenancy.Header != "", "tenancy.header is required")
+	check(c.Tenancy.DefaultTenant == "" || tenant.Valid(c.Tenancy.DefaultTenant),
+		"tenancy.default_tenant must be letters, digits, '.', '_' or '-', got %q", c.Tenancy.DefaultTenant)
+	check(c.Tenancy.MaxUsers >= 0, "tenancy.max_users cannot be negative")
+	for _, id := range slices.Sorted(maps.Keys(c.Tenancy.Quotas)) {
+		check(tenant.Valid(id), "tenancy.quotas: invalid tenant ID %q", id)
+		check(c.Tenancy.Quotas[id] >= 0, "tenancy.quotas.%s cannot be negative", id)
+	}
+
+	return errors.Join(errs...)
 }
 
+// Redacted returns a copy of the configuration with every secret that is
+// set replaced, safe to print or log
+func (c Config) Redacted() Config {
+	walkFields(reflect.ValueOf(&c).Elem(), func(field reflect.StructField, value reflect.Value) {
+		if field.Tag.Get("secret") == "true" && value.String() != "" {
+			value.SetString(redacted)
+		}
+	})
+	return c
+}
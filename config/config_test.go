@@ -0,0 +1,159 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+server:
+  port: 9000
+  env: development
+db:
+  host: file-host
+  name: file-db
+features:
+  idempotency_ttl: 2h
+`)
+	t.Setenv("DB_HOST", "env-host")
+	t.Setenv("PORT", "9100")
+
+	cfg, args, err := Load([]string{"--config", path, "--port", "9200", "migrate", "up"}, &bytes.Buffer{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"migrate", "up"}, args)
+
+	assert.Equal(t, 9200, cfg.Server.Port)                    // flag over env and file
+	assert.Equal(t, "env-host", cfg.DB.Host)                  // env over file
+	assert.Equal(t, "file-db", cfg.DB.Name)                   // file over default
+	assert.Equal(t, 2*time.Hour, cfg.Features.IdempotencyTTL) // durations from YAML
+	assert.Equal(t, 1000, cfg.Features.BatchMaxOperations)    // default
+}
+
+func TestLoadTOML(t *testing.T) {
+	path := writeFile(t, "config.toml", `
+[db]
+port = 6432
+max_conns = 20
+
+[features]
+purge_interval = "30m"
+`)
+
+	cfg, _, err := Load([]string{"--config", path}, &bytes.Buffer{})
+	assert.NoError(t, err)
+	assert.Equal(t, 6432, cfg.DB.Port)
+	assert.Equal(t, int32(20), cfg.DB.MaxConns)
+	assert.Equal(t, 30*time.Minute, cfg.Features.PurgeInterval)
+}
+
+func TestLoadRejectsUnknownFileKeys(t *testing.T) {
+	path := writeFile(t, "config.yaml", "db:\n  hots: localhost\n")
+
+	_, _, err := Load([]string{"--config", path}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "hots")
+}
+
+func TestLoadReportsAllErrors(t *testing.T) {
+	t.Setenv("DB_PORT", "not-a-port")
+	t.Setenv("PURGE_INTERVAL", "often")
+
+	_, _, err := Load(nil, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "env DB_PORT")
+	assert.ErrorContains(t, err, "env PURGE_INTERVAL")
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Server.Env = EnvProduction
+	cfg.Server.Port = 0
+	cfg.Tracing.SampleRatio = 2
+
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "server.port")
+	assert.ErrorContains(t, err, "db.password is required in production")
+	assert.ErrorContains(t, err, "security.cursor_secret")
+	assert.ErrorContains(t, err, "tracing.sample_ratio")
+}
+
+func TestValidateDatabase(t *testing.T) {
+	cfg := Default()
+	cfg.DB.Host = ""
+	cfg.DB.SSLMode = "strict"
+	cfg.DB.SSLCert = "client.pem"
+
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "db.host is required")
+	assert.ErrorContains(t, err, "db.sslmode")
+	assert.ErrorContains(t, err, "db.sslcert and db.sslkey")
+
+	// DATABASE_URL replaces the discrete fields
+	cfg = Default()
+	cfg.DB.Host = ""
+	cfg.DB.URL = "postgres://app:secret@db/app"
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.DB.Password = "hunter2"
+
+	var out bytes.Buffer
+	assert.NoError(t, Print(&out, &cfg))
+	assert.NotContains(t, out.String(), "hunter2")
+	assert.Contains(t, out.String(), "password: '[REDACTED]'")
+	assert.Contains(t, out.String(), "cursor_secret: \"\"")
+	assert.Contains(t, out.String(), "idempotency_ttl: 24h0m0s")
+
+	// The original is left untouched
+	assert.Equal(t, "hunter2", cfg.DB.Password)
+}
+
+func TestValidateJWT(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.JWKSURL = "ftp://auth.internal/jwks.json"
+	cfg.JWT.JWKSFile = "jwks.json"
+
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "cannot both be set")
+	assert.ErrorContains(t, err, "jwt.jwks_url must be an https URL")
+	assert.ErrorContains(t, err, "jwt.issuer is required")
+	assert.ErrorContains(t, err, "jwt.audience is required")
+
+	cfg.JWT.JWKSFile = ""
+	cfg.JWT.JWKSURL = "https://auth.internal/jwks.json"
+	cfg.JWT.Issuer = "https://auth.internal"
+	cfg.JWT.Audience = "go-user-api"
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidateTenancy(t *testing.T) {
+	cfg := Default()
+	cfg.Tenancy.DefaultTenant = "*"
+	cfg.Tenancy.MaxUsers = -1
+	cfg.Tenancy.Quotas = map[string]int{"acme": -5, "not a tenant": 10}
+
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "tenancy.default_tenant must be")
+	assert.ErrorContains(t, err, "tenancy.max_users cannot be negative")
+	assert.ErrorContains(t, err, "tenancy.quotas.acme cannot be negative")
+	assert.ErrorContains(t, err, `invalid tenant ID "not a tenant"`)
+
+	cfg.Tenancy.DefaultTenant = ""
+	cfg.Tenancy.MaxUsers = 1000
+	cfg.Tenancy.Quotas = map[string]int{"acme": 5000}
+	assert.NoError(t, cfg.Validate())
+}
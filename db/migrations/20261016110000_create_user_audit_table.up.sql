@@ -0,0 +1,26 @@
+CREATE TABLE user_audit (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    operation TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    before JSONB,
+    after JSONB,
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_user_audit_user_id_changed_at ON user_audit (user_id, changed_at);
+
+-- Seed history for existing users so point-in-time reads work for them
+INSERT INTO user_audit (user_id, operation, actor, after, changed_at)
+SELECT id,
+       'create',
+       'migration',
+       jsonb_build_object(
+           'id', id,
+           'name', name,
+           'dob', to_char(dob, 'YYYY-MM-DD'),
+           'version', version
+       ),
+       COALESCE(created_at, NOW())
+FROM users;
@@ -0,0 +1,3 @@
+CREATE INDEX idx_users_name ON users (name);
+CREATE INDEX idx_users_dob ON users (dob);
+CREATE INDEX idx_users_created_at ON users (created_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_name_tsv;
+DROP INDEX IF EXISTS idx_users_name_trgm;
@@ -0,0 +1,16 @@
+DROP POLICY IF EXISTS tenant_isolation ON user_audit;
+DROP POLICY IF EXISTS tenant_isolation ON users;
+ALTER TABLE user_audit DISABLE ROW LEVEL SECURITY;
+ALTER TABLE users DISABLE ROW LEVEL SECURITY;
+
+DROP INDEX IF EXISTS idx_users_tenant_created_at;
+DROP INDEX IF EXISTS idx_users_tenant_dob;
+DROP INDEX IF EXISTS idx_users_tenant_name;
+DROP INDEX IF EXISTS idx_users_tenant_id;
+CREATE INDEX idx_users_name ON users (name);
+CREATE INDEX idx_users_dob ON users (dob);
+CREATE INDEX idx_users_created_at ON users (created_at);
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE user_audit DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,30 @@
+-- Existing rows belong to the default tenant. The default is dropped again so
+-- that every insert has to name its tenant.
+ALTER TABLE users ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE users ALTER COLUMN tenant_id DROP DEFAULT;
+
+ALTER TABLE user_audit ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE user_audit ALTER COLUMN tenant_id DROP DEFAULT;
+
+-- API keys bound to a tenant can only act for it; NULL keys may pick one
+ALTER TABLE api_keys ADD COLUMN tenant_id TEXT;
+
+-- Every list query filters on the tenant first
+DROP INDEX IF EXISTS idx_users_name;
+DROP INDEX IF EXISTS idx_users_dob;
+DROP INDEX IF EXISTS idx_users_created_at;
+CREATE INDEX idx_users_tenant_id ON users (tenant_id, id);
+CREATE INDEX idx_users_tenant_name ON users (tenant_id, name);
+CREATE INDEX idx_users_tenant_dob ON users (tenant_id, dob);
+CREATE INDEX idx_users_tenant_created_at ON users (tenant_id, created_at);
+
+-- Row-level security backs up the tenant filter of the queries. The policies
+-- only take effect once RLS is enabled on the tables (see the README); the
+-- server then sets app.tenant_id on every connection it uses.
+CREATE POLICY tenant_isolation ON users
+    USING (tenant_id = current_setting('app.tenant_id', true)
+           OR current_setting('app.tenant_id', true) = '*');
+
+CREATE POLICY tenant_isolation ON user_audit
+    USING (tenant_id = current_setting('app.tenant_id', true)
+           OR current_setting('app.tenant_id', true) = '*');
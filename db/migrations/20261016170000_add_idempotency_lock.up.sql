@@ -0,0 +1,6 @@
+ALTER TABLE idempotency_keys ADD COLUMN locked_until TIMESTAMP;
+
+-- Claims left in progress before this migration get the default lock timeout
+UPDATE idempotency_keys
+SET locked_until = created_at + INTERVAL '1 minute'
+WHERE status_code IS NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE api_keys ALTER COLUMN tenant_id DROP NOT NULL;
+UPDATE api_keys SET tenant_id = NULL WHERE tenant_id = '*';
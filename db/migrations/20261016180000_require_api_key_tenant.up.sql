@@ -0,0 +1,6 @@
+-- Keys minted without a tenant could act for any tenant. They are bound to
+-- the default tenant, which holds every user created before tenancy; a key
+-- that may act for any tenant must be minted again with --all-tenants,
+-- which stores '*'.
+UPDATE api_keys SET tenant_id = 'default' WHERE tenant_id IS NULL;
+ALTER TABLE api_keys ALTER COLUMN tenant_id SET NOT NULL;
@@ -0,0 +1,7 @@
+-- Expiry is written and checked by the server while created_at comes from the
+-- database clock; without a time zone the two drift apart whenever the
+-- session time zone is not UTC. Existing values were written in UTC.
+ALTER TABLE api_keys
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN expires_at TYPE TIMESTAMPTZ USING expires_at AT TIME ZONE 'UTC',
+    ALTER COLUMN revoked_at TYPE TIMESTAMPTZ USING revoked_at AT TIME ZONE 'UTC';
@@ -0,0 +1,2 @@
+ALTER TABLE users
+    ALTER COLUMN deleted_at TYPE TIMESTAMP USING deleted_at AT TIME ZONE 'UTC';
@@ -0,0 +1,5 @@
+-- The purge compares deleted_at with a cutoff computed by the server, which
+-- shifts by the session time zone against a column without one. Existing
+-- values were written in UTC.
+ALTER TABLE users
+    ALTER COLUMN deleted_at TYPE TIMESTAMPTZ USING deleted_at AT TIME ZONE 'UTC';
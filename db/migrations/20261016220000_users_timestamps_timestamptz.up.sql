@@ -0,0 +1,6 @@
+-- The created_after filter compares created_at with a time sent by the
+-- client, which shifts by the session time zone against a column without one.
+-- Existing values were written in UTC.
+ALTER TABLE users
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING updated_at AT TIME ZONE 'UTC';
@@ -0,0 +1,2 @@
+ALTER TABLE user_audit
+    ALTER COLUMN changed_at TYPE TIMESTAMP USING changed_at AT TIME ZONE 'UTC';
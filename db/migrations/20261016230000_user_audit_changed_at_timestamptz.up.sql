@@ -0,0 +1,5 @@
+-- Point-in-time reads compare changed_at with a time sent by the client,
+-- which shifts by the session time zone against a column without one.
+-- Existing values were written in UTC.
+ALTER TABLE user_audit
+    ALTER COLUMN changed_at TYPE TIMESTAMPTZ USING changed_at AT TIME ZONE 'UTC';
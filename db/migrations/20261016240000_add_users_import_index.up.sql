@@ -0,0 +1,4 @@
+-- Imports match each row against the tenant's active users by name (case
+-- insensitive) and date of birth
+CREATE INDEX idx_users_tenant_lower_name_dob ON users (tenant_id, lower(name), dob)
+    WHERE deleted_at IS NULL;
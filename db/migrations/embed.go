@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL migrations so the server binary can apply
+// them without the files being shipped alongside it (see internal/migrate).
+package migrations
+
+import "embed"
+
+// FS holds the <version>_<name>.up.sql and .down.sql files of this directory
+//
+//go:embed *.sql
+var FS embed.FS
@@ -8,10 +8,49 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ApiKey struct {
+	ID        int32
+	Name      string
+	Prefix    string
+	KeyHash   []byte
+	Scopes    []string
+	CreatedAt pgtype.Timestamptz
+	ExpiresAt pgtype.Timestamptz
+	RevokedAt pgtype.Timestamptz
+	TenantID  string
+}
+
+type IdempotencyKey struct {
+	Actor           string
+	Key             string
+	RequestHash     []byte
+	StatusCode      pgtype.Int4
+	ResponseHeaders []byte
+	ResponseBody    []byte
+	CreatedAt       pgtype.Timestamptz
+	ExpiresAt       pgtype.Timestamptz
+	LockedUntil     pgtype.Timestamptz
+}
+
 type User struct {
 	ID        int32
 	Name      string
 	Dob       pgtype.Date
-	CreatedAt pgtype.Timestamp
-	UpdatedAt pgtype.Timestamp
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+	Version   int32
+	DeletedAt pgtype.Timestamptz
+	TenantID  string
+}
+
+type UserAudit struct {
+	ID        int64
+	UserID    int32
+	Operation string
+	Actor     string
+	RequestID string
+	Before    []byte
+	After     []byte
+	ChangedAt pgtype.Timestamptz
+	TenantID  string
 }
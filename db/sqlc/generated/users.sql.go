@@ -13,28 +13,36 @@ import (
 
 const countUsers = `-- name: CountUsers :one
 SELECT COUNT(*) FROM users
+WHERE tenant_id = $1
+  AND ($2::bool OR deleted_at IS NULL)
 `
 
-func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
-	row := q.db.QueryRow(ctx, countUsers)
+type CountUsersParams struct {
+	TenantID       string
+	IncludeDeleted bool
+}
+
+func (q *Queries) CountUsers(ctx context.Context, arg CountUsersParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countUsers, arg.TenantID, arg.IncludeDeleted)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (name, dob)
-VALUES ($1, $2)
-RETURNING id, name, dob, created_at, updated_at
+INSERT INTO users (tenant_id, name, dob)
+VALUES ($1, $2, $3)
+RETURNING id, name, dob, created_at, updated_at, version, deleted_at, tenant_id
 `
 
 type CreateUserParams struct {
-	Name string
-	Dob  pgtype.Date
+	TenantID string
+	Name     string
+	Dob      pgtype.Date
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRow(ctx, createUser, arg.Name, arg.Dob)
+	row := q.db.QueryRow(ctx, createUser, arg.TenantID, arg.Name, arg.Dob)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -42,28 +50,62 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Dob,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
-const deleteUser = `-- name: DeleteUser :exec
-DELETE FROM users
-WHERE id = $1
+const deleteUser = `-- name: DeleteUser :one
+UPDATE users
+SET deleted_at = NOW(),
+    updated_at = NOW(),
+    version = version + 1
+WHERE tenant_id = $1
+  AND id = $2
+  AND deleted_at IS NULL
+  AND ($3::int[] IS NULL OR version = ANY($3::int[]))
+RETURNING id, name, dob, created_at, updated_at, version, deleted_at, tenant_id
 `
 
-func (q *Queries) DeleteUser(ctx context.Context, id int32) error {
-	_, err := q.db.Exec(ctx, deleteUser, id)
-	return err
+type DeleteUserParams struct {
+	TenantID         string
+	ID               int32
+	ExpectedVersions []int32
+}
+
+func (q *Queries) DeleteUser(ctx context.Context, arg DeleteUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, deleteUser, arg.TenantID, arg.ID, arg.ExpectedVersions)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Dob,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.TenantID,
+	)
+	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, name, dob, created_at, updated_at
+SELECT id, name, dob, created_at, updated_at, version, deleted_at, tenant_id
 FROM users
-WHERE id = $1
+WHERE tenant_id = $1
+  AND id = $2
+  AND deleted_at IS NULL
 `
 
-func (q *Queries) GetUserByID(ctx context.Context, id int32) (User, error) {
-	row := q.db.QueryRow(ctx, getUserByID, id)
+type GetUserByIDParams struct {
+	TenantID string
+	ID       int32
+}
+
+func (q *Queries) GetUserByID(ctx context.Context, arg GetUserByIDParams) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByID, arg.TenantID, arg.ID)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -71,24 +113,65 @@ func (q *Queries) GetUserByID(ctx context.Context, id int32) (User, error) {
 		&i.Dob,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const getUserByIDForUpdate = `-- name: GetUserByIDForUpdate :one
+SELECT id, name, dob, created_at, updated_at, version, deleted_at, tenant_id
+FROM users
+WHERE tenant_id = $1
+  AND id = $2
+FOR UPDATE
+`
+
+type GetUserByIDForUpdateParams struct {
+	TenantID string
+	ID       int32
+}
+
+func (q *Queries) GetUserByIDForUpdate(ctx context.Context, arg GetUserByIDForUpdateParams) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByIDForUpdate, arg.TenantID, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Dob,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT id, name, dob, created_at, updated_at
+SELECT id, name, dob, created_at, updated_at, version, deleted_at, tenant_id
 FROM users
+WHERE tenant_id = $1
+  AND ($2::bool OR deleted_at IS NULL)
 ORDER BY id
-LIMIT $1 OFFSET $2
+LIMIT $3 OFFSET $4
 `
 
 type ListUsersParams struct {
-	Limit  int32
-	Offset int32
+	TenantID       string
+	IncludeDeleted bool
+	Limit          int32
+	Offset         int32
 }
 
 func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
-	rows, err := q.db.Query(ctx, listUsers, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listUsers,
+		arg.TenantID,
+		arg.IncludeDeleted,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -102,6 +185,225 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.Dob,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersAfter = `-- name: ListUsersAfter :many
+SELECT id, name, dob, created_at, updated_at, version, deleted_at, tenant_id
+FROM users
+WHERE tenant_id = $1
+  AND id > $2
+  AND ($3::bool OR deleted_at IS NULL)
+ORDER BY id
+LIMIT $4
+`
+
+type ListUsersAfterParams struct {
+	TenantID       string
+	AfterID        int32
+	IncludeDeleted bool
+	Limit          int32
+}
+
+func (q *Queries) ListUsersAfter(ctx context.Context, arg ListUsersAfterParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsersAfter,
+		arg.TenantID,
+		arg.AfterID,
+		arg.IncludeDeleted,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Dob,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const patchUser = `-- name: PatchUser :one
+UPDATE users
+SET name = COALESCE($1, name),
+    dob = COALESCE($2, dob),
+    updated_at = NOW(),
+    version = version + 1
+WHERE tenant_id = $3
+  AND id = $4
+  AND deleted_at IS NULL
+  AND ($5::text IS NULL OR name = $5)
+  AND ($6::date IS NULL OR dob = $6)
+  AND ($7::int[] IS NULL OR version = ANY($7::int[]))
+RETURNING id, name, dob, created_at, updated_at, version, deleted_at, tenant_id
+`
+
+type PatchUserParams struct {
+	Name             pgtype.Text
+	Dob              pgtype.Date
+	TenantID         string
+	ID               int32
+	ExpectedName     pgtype.Text
+	ExpectedDob      pgtype.Date
+	ExpectedVersions []int32
+}
+
+func (q *Queries) PatchUser(ctx context.Context, arg PatchUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, patchUser,
+		arg.Name,
+		arg.Dob,
+		arg.TenantID,
+		arg.ID,
+		arg.ExpectedName,
+		arg.ExpectedDob,
+		arg.ExpectedVersions,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Dob,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const purgeDeletedUsers = `-- name: PurgeDeletedUsers :execrows
+DELETE FROM users
+WHERE ($1::text IS NULL OR tenant_id = $1)
+  AND deleted_at IS NOT NULL
+  AND deleted_at < $2
+`
+
+type PurgeDeletedUsersParams struct {
+	TenantID      pgtype.Text
+	DeletedBefore pgtype.Timestamptz
+}
+
+// A NULL tenant purges every tenant.
+func (q *Queries) PurgeDeletedUsers(ctx context.Context, arg PurgeDeletedUsersParams) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDeletedUsers, arg.TenantID, arg.DeletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreUser = `-- name: RestoreUser :one
+UPDATE users
+SET deleted_at = NULL,
+    updated_at = NOW(),
+    version = version + 1
+WHERE tenant_id = $1
+  AND id = $2
+  AND deleted_at IS NOT NULL
+RETURNING id, name, dob, created_at, updated_at, version, deleted_at, tenant_id
+`
+
+type RestoreUserParams struct {
+	TenantID string
+	ID       int32
+}
+
+func (q *Queries) RestoreUser(ctx context.Context, arg RestoreUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, restoreUser, arg.TenantID, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Dob,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const searchUsers = `-- name: SearchUsers :many
+SELECT id, name, dob, created_at, updated_at, version, deleted_at, tenant_id,
+       GREATEST(
+           similarity(name, $1::text),
+           ts_rank(to_tsvector('simple', name), plainto_tsquery('simple', $1::text))
+       )::real AS score
+FROM users
+WHERE tenant_id = $2
+  AND deleted_at IS NULL
+  AND (name % $1::text
+       OR to_tsvector('simple', name) @@ plainto_tsquery('simple', $1::text))
+ORDER BY score DESC, id
+LIMIT $3
+`
+
+type SearchUsersParams struct {
+	Query    string
+	TenantID string
+	Limit    int32
+}
+
+type SearchUsersRow struct {
+	ID        int32
+	Name      string
+	Dob       pgtype.Date
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+	Version   int32
+	DeletedAt pgtype.Timestamptz
+	TenantID  string
+	Score     float32
+}
+
+func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]SearchUsersRow, error) {
+	rows, err := q.db.Query(ctx, searchUsers, arg.Query, arg.TenantID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchUsersRow
+	for rows.Next() {
+		var i SearchUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Dob,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.DeletedAt,
+			&i.TenantID,
+			&i.Score,
 		); err != nil {
 			return nil, err
 		}
@@ -115,21 +417,33 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
-SET name = $2,
-    dob = $3,
-    updated_at = NOW()
-WHERE id = $1
-RETURNING id, name, dob, created_at, updated_at
+SET name = $1,
+    dob = $2,
+    updated_at = NOW(),
+    version = version + 1
+WHERE tenant_id = $3
+  AND id = $4
+  AND deleted_at IS NULL
+  AND ($5::int[] IS NULL OR version = ANY($5::int[]))
+RETURNING id, name, dob, created_at, updated_at, version, deleted_at, tenant_id
 `
 
 type UpdateUserParams struct {
-	ID   int32
-	Name string
-	Dob  pgtype.Date
+	Name             string
+	Dob              pgtype.Date
+	TenantID         string
+	ID               int32
+	ExpectedVersions []int32
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
-	row := q.db.QueryRow(ctx, updateUser, arg.ID, arg.Name, arg.Dob)
+	row := q.db.QueryRow(ctx, updateUser,
+		arg.Name,
+		arg.Dob,
+		arg.TenantID,
+		arg.ID,
+		arg.ExpectedVersions,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -137,6 +451,9 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Dob,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.TenantID,
 	)
 	return i, err
 }
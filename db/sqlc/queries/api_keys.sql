@@ -0,0 +1,33 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (name, prefix, key_hash, scopes, expires_at, tenant_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, name, prefix, key_hash, scopes, created_at, expires_at, revoked_at, tenant_id;
+
+-- name: GetAPIKey :one
+SELECT id, name, prefix, key_hash, scopes, created_at, expires_at, revoked_at, tenant_id
+FROM api_keys
+WHERE id = $1;
+
+-- name: GetAPIKeyByPrefix :one
+SELECT id, name, prefix, key_hash, scopes, created_at, expires_at, revoked_at, tenant_id
+FROM api_keys
+WHERE prefix = $1;
+
+-- name: ListAPIKeys :many
+SELECT id, name, prefix, key_hash, scopes, created_at, expires_at, revoked_at, tenant_id
+FROM api_keys
+ORDER BY id;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL;
+
+-- name: ExpireAPIKey :execrows
+-- Brings the expiry of a key forward; a key that already expires earlier is
+-- left alone.
+UPDATE api_keys
+SET expires_at = $2
+WHERE id = $1
+  AND revoked_at IS NULL
+  AND (expires_at IS NULL OR expires_at > $2);
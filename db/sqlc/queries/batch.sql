@@ -0,0 +1,39 @@
+-- name: GetUsersByIDsForUpdate :many
+SELECT id, name, dob, created_at, updated_at, version, deleted_at, tenant_id
+FROM users
+WHERE tenant_id = sqlc.arg('tenant_id')
+  AND id = ANY(sqlc.arg('ids')::int[])
+ORDER BY id
+FOR UPDATE;
+
+-- name: CreateUsersBatch :batchone
+INSERT INTO users (tenant_id, name, dob)
+VALUES ($1, $2, $3)
+RETURNING id, name, dob, created_at, updated_at, version, deleted_at, tenant_id;
+
+-- name: UpdateUsersBatch :batchone
+UPDATE users
+SET name = sqlc.arg('name'),
+    dob = sqlc.arg('dob'),
+    updated_at = NOW(),
+    version = version + 1
+WHERE tenant_id = sqlc.arg('tenant_id')
+  AND id = sqlc.arg('id')
+  AND deleted_at IS NULL
+  AND (sqlc.narg('expected_versions')::int[] IS NULL OR version = ANY(sqlc.narg('expected_versions')::int[]))
+RETURNING id, name, dob, created_at, updated_at, version, deleted_at, tenant_id;
+
+-- name: DeleteUsersBatch :batchone
+UPDATE users
+SET deleted_at = NOW(),
+    updated_at = NOW(),
+    version = version + 1
+WHERE tenant_id = sqlc.arg('tenant_id')
+  AND id = sqlc.arg('id')
+  AND deleted_at IS NULL
+  AND (sqlc.narg('expected_versions')::int[] IS NULL OR version = ANY(sqlc.narg('expected_versions')::int[]))
+RETURNING id, name, dob, created_at, updated_at, version, deleted_at, tenant_id;
+
+-- name: InsertUserAudits :copyfrom
+INSERT INTO user_audit (tenant_id, user_id, operation, actor, request_id, before, after)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
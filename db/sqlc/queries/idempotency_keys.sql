@@ -0,0 +1,40 @@
+-- name: ClaimIdempotencyKey :one
+-- Inserts a new key, or takes over an expired one or one whose request never
+-- completed it within the lock timeout (e.g. the server crashed), if the
+-- request is the same. Returns no row when the key is already held.
+INSERT INTO idempotency_keys (actor, key, request_hash, expires_at, locked_until)
+VALUES (sqlc.arg('actor'), sqlc.arg('key'), sqlc.arg('request_hash'), sqlc.arg('expires_at'), NOW() + sqlc.arg('lock_timeout')::interval)
+ON CONFLICT (actor, key) DO UPDATE
+SET request_hash = EXCLUDED.request_hash,
+    status_code = NULL,
+    response_headers = NULL,
+    response_body = NULL,
+    created_at = NOW(),
+    expires_at = EXCLUDED.expires_at,
+    locked_until = EXCLUDED.locked_until
+WHERE idempotency_keys.expires_at < NOW()
+   OR (idempotency_keys.status_code IS NULL
+       AND idempotency_keys.locked_until < NOW()
+       AND idempotency_keys.request_hash = EXCLUDED.request_hash)
+RETURNING actor, key, request_hash, status_code, response_headers, response_body, created_at, expires_at, locked_until;
+
+-- name: GetIdempotencyKey :one
+SELECT actor, key, request_hash, status_code, response_headers, response_body, created_at, expires_at, locked_until
+FROM idempotency_keys
+WHERE actor = $1 AND key = $2;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET status_code = $3,
+    response_headers = $4,
+    response_body = $5,
+    locked_until = NULL
+WHERE actor = $1 AND key = $2;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE actor = $1 AND key = $2;
+
+-- name: PurgeExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE expires_at < $1;
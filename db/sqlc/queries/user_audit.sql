@@ -0,0 +1,25 @@
+-- name: InsertUserAudit :exec
+INSERT INTO user_audit (tenant_id, user_id, operation, actor, request_id, before, after)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ListUserAudit :many
+SELECT id, user_id, operation, actor, request_id, before, after, changed_at, tenant_id
+FROM user_audit
+WHERE tenant_id = $1
+  AND user_id = $2
+ORDER BY changed_at DESC, id DESC
+LIMIT $3 OFFSET $4;
+
+-- name: CountUserAudit :one
+SELECT COUNT(*) FROM user_audit
+WHERE tenant_id = $1
+  AND user_id = $2;
+
+-- name: GetUserAuditAsOf :one
+SELECT id, user_id, operation, actor, request_id, before, after, changed_at, tenant_id
+FROM user_audit
+WHERE tenant_id = $1
+  AND user_id = $2
+  AND changed_at <= $3
+ORDER BY changed_at DESC, id DESC
+LIMIT 1;
@@ -1,31 +1,108 @@
 -- name: CreateUser :one
-INSERT INTO users (name, dob)
-VALUES ($1, $2)
-RETURNING id, name, dob, created_at, updated_at;
+INSERT INTO users (tenant_id, name, dob)
+VALUES ($1, $2, $3)
+RETURNING id, name, dob, created_at, updated_at, version, deleted_at, tenant_id;
 
 -- name: GetUserByID :one
-SELECT id, name, dob, created_at, updated_at
+SELECT id, name, dob, created_at, updated_at, version, deleted_at, tenant_id
 FROM users
-WHERE id = $1;
+WHERE tenant_id = $1
+  AND id = $2
+  AND deleted_at IS NULL;
+
+-- name: GetUserByIDForUpdate :one
+SELECT id, name, dob, created_at, updated_at, version, deleted_at, tenant_id
+FROM users
+WHERE tenant_id = $1
+  AND id = $2
+FOR UPDATE;
 
 -- name: ListUsers :many
-SELECT id, name, dob, created_at, updated_at
+SELECT id, name, dob, created_at, updated_at, version, deleted_at, tenant_id
+FROM users
+WHERE tenant_id = sqlc.arg('tenant_id')
+  AND (sqlc.arg('include_deleted')::bool OR deleted_at IS NULL)
+ORDER BY id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListUsersAfter :many
+SELECT id, name, dob, created_at, updated_at, version, deleted_at, tenant_id
 FROM users
+WHERE tenant_id = sqlc.arg('tenant_id')
+  AND id > sqlc.arg('after_id')
+  AND (sqlc.arg('include_deleted')::bool OR deleted_at IS NULL)
 ORDER BY id
-LIMIT $1 OFFSET $2;
+LIMIT sqlc.arg('limit');
 
 -- name: CountUsers :one
-SELECT COUNT(*) FROM users;
+SELECT COUNT(*) FROM users
+WHERE tenant_id = sqlc.arg('tenant_id')
+  AND (sqlc.arg('include_deleted')::bool OR deleted_at IS NULL);
 
 -- name: UpdateUser :one
 UPDATE users
-SET name = $2,
-    dob = $3,
-    updated_at = NOW()
-WHERE id = $1
-RETURNING id, name, dob, created_at, updated_at;
+SET name = sqlc.arg('name'),
+    dob = sqlc.arg('dob'),
+    updated_at = NOW(),
+    version = version + 1
+WHERE tenant_id = sqlc.arg('tenant_id')
+  AND id = sqlc.arg('id')
+  AND deleted_at IS NULL
+  AND (sqlc.narg('expected_versions')::int[] IS NULL OR version = ANY(sqlc.narg('expected_versions')::int[]))
+RETURNING id, name, dob, created_at, updated_at, version, deleted_at, tenant_id;
+
+-- name: PatchUser :one
+UPDATE users
+SET name = COALESCE(sqlc.narg('name'), name),
+    dob = COALESCE(sqlc.narg('dob'), dob),
+    updated_at = NOW(),
+    version = version + 1
+WHERE tenant_id = sqlc.arg('tenant_id')
+  AND id = sqlc.arg('id')
+  AND deleted_at IS NULL
+  AND (sqlc.narg('expected_name')::text IS NULL OR name = sqlc.narg('expected_name'))
+  AND (sqlc.narg('expected_dob')::date IS NULL OR dob = sqlc.narg('expected_dob'))
+  AND (sqlc.narg('expected_versions')::int[] IS NULL OR version = ANY(sqlc.narg('expected_versions')::int[]))
+RETURNING id, name, dob, created_at, updated_at, version, deleted_at, tenant_id;
+
+-- name: DeleteUser :one
+UPDATE users
+SET deleted_at = NOW(),
+    updated_at = NOW(),
+    version = version + 1
+WHERE tenant_id = sqlc.arg('tenant_id')
+  AND id = sqlc.arg('id')
+  AND deleted_at IS NULL
+  AND (sqlc.narg('expected_versions')::int[] IS NULL OR version = ANY(sqlc.narg('expected_versions')::int[]))
+RETURNING id, name, dob, created_at, updated_at, version, deleted_at, tenant_id;
 
--- name: DeleteUser :exec
+-- name: RestoreUser :one
+UPDATE users
+SET deleted_at = NULL,
+    updated_at = NOW(),
+    version = version + 1
+WHERE tenant_id = $1
+  AND id = $2
+  AND deleted_at IS NOT NULL
+RETURNING id, name, dob, created_at, updated_at, version, deleted_at, tenant_id;
+
+-- name: PurgeDeletedUsers :execrows
+-- A NULL tenant purges every tenant.
 DELETE FROM users
-WHERE id = $1;
+WHERE (sqlc.narg('tenant_id')::text IS NULL OR tenant_id = sqlc.narg('tenant_id'))
+  AND deleted_at IS NOT NULL
+  AND deleted_at < sqlc.arg('deleted_before');
 
+-- name: SearchUsers :many
+SELECT id, name, dob, created_at, updated_at, version, deleted_at, tenant_id,
+       GREATEST(
+           similarity(name, sqlc.arg('query')::text),
+           ts_rank(to_tsvector('simple', name), plainto_tsquery('simple', sqlc.arg('query')::text))
+       )::real AS score
+FROM users
+WHERE tenant_id = sqlc.arg('tenant_id')
+  AND deleted_at IS NULL
+  AND (name % sqlc.arg('query')::text
+       OR to_tsvector('simple', name) @@ plainto_tsquery('simple', sqlc.arg('query')::text))
+ORDER BY score DESC, id
+LIMIT sqlc.arg('limit');
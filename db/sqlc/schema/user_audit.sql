@@ -0,0 +1,13 @@
+CREATE TABLE user_audit (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    operation TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    before JSONB,
+    after JSONB,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tenant_id TEXT NOT NULL
+);
+
+CREATE INDEX idx_user_audit_user_id_changed_at ON user_audit (user_id, changed_at);
@@ -2,6 +2,24 @@ CREATE TABLE users (
     id SERIAL PRIMARY KEY,
     name TEXT NOT NULL,
     dob DATE NOT NULL,
-    created_at TIMESTAMP DEFAULT NOW(),
-    updated_at TIMESTAMP DEFAULT NOW()
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    version INTEGER NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMPTZ,
+    tenant_id TEXT NOT NULL
 );
+
+CREATE INDEX idx_users_deleted_at ON users (deleted_at)
+    WHERE deleted_at IS NOT NULL;
+
+CREATE INDEX idx_users_tenant_id ON users (tenant_id, id);
+CREATE INDEX idx_users_tenant_name ON users (tenant_id, name);
+CREATE INDEX idx_users_tenant_dob ON users (tenant_id, dob);
+CREATE INDEX idx_users_tenant_created_at ON users (tenant_id, created_at);
+CREATE INDEX idx_users_tenant_lower_name_dob ON users (tenant_id, lower(name), dob)
+    WHERE deleted_at IS NULL;
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_users_name_trgm ON users USING GIN (name gin_trgm_ops);
+CREATE INDEX idx_users_name_tsv ON users USING GIN (to_tsvector('simple', name));
@@ -0,0 +1,51 @@
+/*
+Package audit carries the metadata recorded with every change to a user.
+Middleware stores the acting principal and the request ID in the request
+context; the repository reads them back when writing the user_audit row.
+*/
+package audit
+
+import "context"
+
+// Operations recorded in user_audit.operation
+const (
+	OperationCreate  = "create"
+	OperationUpdate  = "update"
+	OperationPatch   = "patch"
+	OperationDelete  = "delete"
+	OperationRestore = "restore"
+)
+
+// AnonymousActor is recorded when no caller identity is known
+const AnonymousActor = "anonymous"
+
+type contextKey int
+
+const (
+	actorKey contextKey = iota
+	requestIDKey
+)
+
+// WithActor returns a copy of ctx carrying the acting principal
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// Actor returns the acting principal stored in ctx, or AnonymousActor
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey).(string); ok && actor != "" {
+		return actor
+	}
+	return AnonymousActor
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the request ID stored in ctx, if any
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
@@ -0,0 +1,292 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"go.uber.org/zap"
+)
+
+// minReloadInterval limits reloads triggered by tokens signed with an
+// unknown key, so bad tokens cannot hammer the JWKS endpoint
+const minReloadInterval = 30 * time.Second
+
+// reloadTimeout bounds a background reload, which no request can cancel
+const reloadTimeout = 10 * time.Second
+
+// maxJWKSSize bounds the JWKS document we are willing to read
+const maxJWKSSize = 1 << 20
+
+// minRSABits rejects RSA keys too short to be trusted
+const minRSABits = 2048
+
+var errUnknownKey = errors.New("token is signed with an unknown key")
+
+// KeySet caches the public keys of a JWKS document (RFC 7517) by key ID.
+// The document is loaded again after the refresh interval, and earlier when a
+// token names a key that is not in the cache. If a reload fails the cached
+// keys are kept. Reloads run in the background, one at a time, and the cached
+// keys are served meanwhile.
+type KeySet struct {
+	load    func(ctx context.Context) ([]byte, error)
+	refresh time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	loadedAt  time.Time
+	attemptAt time.Time
+	reloading chan struct{} // closed when the reload in flight ends, nil if none
+}
+
+// NewFileKeySet returns a KeySet read from a local JWKS file
+func NewFileKeySet(path string, refresh time.Duration) *KeySet {
+	return &KeySet{
+		refresh: refresh,
+		load: func(ctx context.Context) ([]byte, error) {
+			return os.ReadFile(path)
+		},
+	}
+}
+
+// NewURLKeySet returns a KeySet fetched from url with client
+func NewURLKeySet(url string, client *http.Client, refresh time.Duration) *KeySet {
+	return &KeySet{
+		refresh: refresh,
+		load: func(ctx context.Context) ([]byte, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("fetching JWKS: unexpected status %s", resp.Status)
+			}
+			return io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+		},
+	}
+}
+
+// Load loads the keys now. Call it at startup to fail fast on a bad JWKS.
+func (s *KeySet) Load(ctx context.Context) error {
+	attemptAt := time.Now()
+	keys, err := s.fetch(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attemptAt = attemptAt
+	if err != nil {
+		return err
+	}
+	s.keys = keys
+	s.loadedAt = attemptAt
+	return nil
+}
+
+// Key returns the public key with the given ID. An empty kid matches the
+// only key of a single-key set. A stale cache is refreshed in the background;
+// an unknown kid waits for the reload in flight, if any, or until ctx is done.
+func (s *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	now := time.Now()
+	stale := now.Sub(s.loadedAt) >= s.refresh
+	key, ok := s.lookup(kid)
+	done := s.reloading
+	if done == nil && (stale || !ok) && now.Sub(s.attemptAt) >= minReloadInterval {
+		done = s.startReload()
+	}
+	s.mu.Unlock()
+
+	if ok {
+		return key, nil
+	}
+	if done == nil {
+		return nil, errUnknownKey
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, errUnknownKey
+}
+
+func (s *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// startReload reloads the keys in the background and returns a channel
+// closed once it is done; s.mu must be held
+func (s *KeySet) startReload() chan struct{} {
+	done := make(chan struct{})
+	s.reloading = done
+	s.attemptAt = time.Now()
+	attemptAt := s.attemptAt
+
+	go func() {
+		defer close(done)
+
+		// The reload is shared, so it must not end with the request that started it
+		ctx, cancel := context.WithTimeout(context.Background(), reloadTimeout)
+		defer cancel()
+		keys, err := s.fetch(ctx)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.reloading = nil
+		if err != nil {
+			logger.FromContext(ctx).Warn("Failed to reload JWKS, keeping cached keys", zap.Error(err))
+			return
+		}
+		s.keys = keys
+		s.loadedAt = attemptAt
+	}()
+	return done
+}
+
+// fetch loads and parses the JWKS document
+func (s *KeySet) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	data, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+// jwk holds the members of a JSON Web Key used for RSA, EC and OKP keys
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS returns the signature keys of a JWKS document by key ID.
+// Encryption keys and unsupported key types are skipped.
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %q: %w", k.Kid, err)
+		}
+		if key != nil {
+			keys[k.Kid] = key
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("invalid JWKS: no signature keys")
+	}
+	return keys, nil
+}
+
+// publicKey decodes k, returning nil for key types and curves we do not support
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		if n.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA key shorter than %d bits", minRSABits)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		var ecdhCurve ecdh.Curve
+		switch k.Crv {
+		case "P-256":
+			curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+		case "P-384":
+			curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+		case "P-521":
+			curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+		default:
+			return nil, nil
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil || len(x) != size || len(y) != size {
+			return nil, errors.New("invalid EC coordinates")
+		}
+		// ecdh rejects points that are not on the curve
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, nil
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+)
+
+// ErrInvalidToken is returned for bearer tokens that fail any check; the
+// wrapped error says which
+var ErrInvalidToken = errors.New("invalid token")
+
+// signingMethods are the accepted algorithms. Others, "none" and HMAC in
+// particular, are rejected before any key is looked up.
+var signingMethods = []string{"RS256", "ES256", "EdDSA"}
+
+// tokenClaims are the claims read from a token. Scopes come from the OAuth 2.0
+// "scope" claim (space separated) or the "scp" array; unknown ones are ignored.
+// "roles" and "user_id" feed the Policy, and the required "tenant_id" binds
+// the token to a tenant, or to every tenant with tenant.All.
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Scope    string   `json:"scope"`
+	Scp      []string `json:"scp"`
+	Roles    []string `json:"roles"`
+	UserID   int32    `json:"user_id"`
+	TenantID string   `json:"tenant_id"`
+}
+
+// JWTVerifier checks bearer tokens against a KeySet
+type JWTVerifier struct {
+	keys   *KeySet
+	parser *jwt.Parser
+}
+
+// NewJWTVerifier returns a verifier accepting tokens signed by a key of keys,
+// issued by issuer for audience. exp is required; exp and nbf are checked
+// with the given leeway for clock skew.
+func NewJWTVerifier(keys *KeySet, issuer, audience string, leeway time.Duration) *JWTVerifier {
+	return &JWTVerifier{
+		keys: keys,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods(signingMethods),
+			jwt.WithIssuer(issuer),
+			jwt.WithAudience(audience),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(leeway),
+		),
+	}
+}
+
+// Verify checks token and maps its claims to a principal
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (Principal, error) {
+	var claims tokenClaims
+	_, err := v.parser.ParseWithClaims(token, &claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if claims.Subject == "" {
+		return Principal{}, fmt.Errorf("%w: missing sub claim", ErrInvalidToken)
+	}
+	if claims.TenantID == "" {
+		return Principal{}, fmt.Errorf("%w: missing tenant_id claim", ErrInvalidToken)
+	}
+	if claims.TenantID != tenant.All && !tenant.Valid(claims.TenantID) {
+		return Principal{}, fmt.Errorf("%w: invalid tenant_id claim", ErrInvalidToken)
+	}
+
+	var scopes []Scope
+	for _, name := range append(strings.Fields(claims.Scope), claims.Scp...) {
+		scope := Scope(name)
+		if slices.Contains(scopeLevels, scope) && !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return Principal{
+		Subject: claims.Subject,
+		Scopes:  scopes,
+		Roles:   claims.Roles,
+		UserID:  claims.UserID,
+		Tenant:  claims.TenantID,
+	}, nil
+}
@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "go-user-api"
+)
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// testJWK encodes the public half of key as a JWK
+func testJWK(kid string, key crypto.Signer) map[string]string {
+	switch pub := key.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": kid, "n": b64(pub.N.Bytes()), "e": b64(big.NewInt(int64(pub.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(pub.X.FillBytes(make([]byte, 32))), "y": b64(pub.Y.FillBytes(make([]byte, 32)))}
+	case ed25519.PublicKey:
+		return map[string]string{"kty": "OKP", "kid": kid, "crv": "Ed25519", "x": b64(pub)}
+	}
+	panic("unsupported key")
+}
+
+func TestJWTVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	doc, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		testJWK("rsa", rsaKey), testJWK("ec", ecKey), testJWK("ed", edKey),
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}})
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(path, doc, 0o600))
+
+	keys := NewFileKeySet(path, time.Hour)
+	assert.NoError(t, keys.Load(context.Background()))
+	verifier := NewJWTVerifier(keys, testIssuer, testAudience, time.Minute)
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":       testIssuer,
+			"aud":       testAudience,
+			"sub":       "billing-service",
+			"exp":       time.Now().Add(time.Hour).Unix(),
+			"scope":     "users:read openid users:write",
+			"tenant_id": "acme",
+		}
+	}
+	sign := func(method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		assert.NoError(t, err)
+		return signed
+	}
+
+	for _, tt := range []struct {
+		method jwt.SigningMethod
+		kid    string
+		key    any
+	}{
+		{jwt.SigningMethodRS256, "rsa", rsaKey},
+		{jwt.SigningMethodES256, "ec", ecKey},
+		{jwt.SigningMethodEdDSA, "ed", edKey},
+	} {
+		t.Run(tt.method.Alg(), func(t *testing.T) {
+			principal, err := verifier.Verify(context.Background(), sign(tt.method, tt.kid, tt.key, valid()))
+			assert.NoError(t, err)
+			assert.Equal(t, "billing-service", principal.Subject)
+			assert.Equal(t, []Scope{ScopeUsersRead, ScopeUsersWrite}, principal.Scopes)
+			assert.Equal(t, "acme", principal.Tenant)
+		})
+	}
+
+	modified := func(key string, value any) jwt.MapClaims {
+		claims := valid()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+	rejected := map[string]string{
+		"Wrong issuer":       sign(jwt.SigningMethodRS256, "rsa", rsaKey, modified("iss", "https://evil.example.com")),
+		"Wrong audience":     sign(jwt.SigningMethodRS256, "rsa", rsaKey, modified("aud", "other-api")),
+		"Expired":            sign(jwt.SigningMethodRS256, "rsa", rsaKey, modified("exp", time.Now().Add(-2*time.Minute).Unix())),
+		"Missing exp":        sign(jwt.SigningMethodRS256, "rsa", rsaKey, modified("exp", nil)),
+		"Not yet valid":      sign(jwt.SigningMethodRS256, "rsa", rsaKey, modified("nbf", time.Now().Add(2*time.Minute).Unix())),
+		"Missing subject":    sign(jwt.SigningMethodRS256, "rsa", rsaKey, modified("sub", nil)),
+		"Missing tenant":     sign(jwt.SigningMethodRS256, "rsa", rsaKey, modified("tenant_id", nil)),
+		"Invalid tenant":     sign(jwt.SigningMethodRS256, "rsa", rsaKey, modified("tenant_id", "Not a tenant")),
+		"Unknown key":        sign(jwt.SigningMethodRS256, "other", rsaKey, valid()),
+		"Key of another alg": sign(jwt.SigningMethodES256, "rsa", ecKey, valid()),
+		"HMAC":               sign(jwt.SigningMethodHS256, "rsa", []byte("secret"), valid()),
+		"Malformed":          "not.a.token",
+	}
+	for name, token := range rejected {
+		t.Run(name, func(t *testing.T) {
+			_, err := verifier.Verify(context.Background(), token)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+
+	// Within the leeway, a just expired token is still accepted
+	_, err = verifier.Verify(context.Background(), sign(jwt.SigningMethodRS256, "rsa", rsaKey, modified("exp", time.Now().Add(-30*time.Second).Unix())))
+	assert.NoError(t, err)
+}
+
+func TestParseJWKSRejectsBadKeys(t *testing.T) {
+	_, err := ParseJWKS([]byte(`{"keys": []}`))
+	assert.Error(t, err)
+
+	// A short RSA modulus
+	_, err = ParseJWKS([]byte(`{"keys": [{"kty": "RSA", "kid": "a", "n": "AQAB", "e": "AQAB"}]}`))
+	assert.ErrorContains(t, err, "shorter than")
+
+	// A point that is not on P-256
+	zero := b64(make([]byte, 32))
+	_, err = ParseJWKS([]byte(`{"keys": [{"kty": "EC", "kid": "a", "crv": "P-256", "x": "` + zero + `", "y": "` + zero + `"}]}`))
+	assert.ErrorContains(t, err, "not on the curve")
+}
+
+func TestKeySetReloadsInBackground(t *testing.T) {
+	_, oldKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	_, newKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	before, err := json.Marshal(map[string]any{"keys": []map[string]string{testJWK("old", oldKey)}})
+	assert.NoError(t, err)
+	after, err := json.Marshal(map[string]any{"keys": []map[string]string{testJWK("old", oldKey), testJWK("new", newKey)}})
+	assert.NoError(t, err)
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	keys := &KeySet{refresh: time.Hour, load: func(ctx context.Context) ([]byte, error) {
+		if loads.Add(1) == 1 {
+			return before, nil
+		}
+		<-release
+		return after, nil
+	}}
+	assert.NoError(t, keys.Load(context.Background()))
+	keys.loadedAt = time.Now().Add(-2 * time.Hour)
+	keys.attemptAt = keys.loadedAt
+
+	// The stale cache starts a reload but is served while it blocks
+	key, err := keys.Key(context.Background(), "old")
+	assert.NoError(t, err)
+	assert.Equal(t, oldKey.Public(), key)
+
+	// A caller giving up on an unknown key does not abort the reload
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = keys.Key(ctx, "new")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key, err := keys.Key(context.Background(), "new")
+			assert.NoError(t, err)
+			assert.Equal(t, newKey.Public(), key)
+		}()
+	}
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(2), loads.Load())
+}
+
+func TestPrincipalHasScope(t *testing.T) {
+	writer := Principal{Scopes: []Scope{ScopeUsersWrite}}
+	assert.True(t, writer.HasScope(ScopeUsersRead))
+	assert.True(t, writer.HasScope(ScopeUsersWrite))
+	assert.False(t, writer.HasScope(ScopeUsersAdmin))
+	assert.False(t, writer.HasScope("users:unknown"))
+
+	scopes, err := ParseScopes("users:read, users:admin,users:read")
+	assert.NoError(t, err)
+	assert.Equal(t, []Scope{ScopeUsersRead, ScopeUsersAdmin}, scopes)
+	_, err = ParseScopes("users:everything")
+	assert.Error(t, err)
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Permission allows one user operation. Granting "<permission>:own" instead
+// allows it only on the caller's own record, the user whose ID is the
+// principal's UserID.
+type Permission string
+
+const (
+	PermUserRead        Permission = "user:read"
+	PermUserList        Permission = "user:list"         // list, search and export
+	PermUserListDeleted Permission = "user:list_deleted" // include soft-deleted users in list and export
+	PermUserCreate      Permission = "user:create"
+	PermUserUpdate      Permission = "user:update" // PUT and PATCH
+	PermUserDelete      Permission = "user:delete"
+	PermUserRestore     Permission = "user:restore"
+	PermUserHistory     Permission = "user:history" // audit trail and as_of reads
+	PermUserImport      Permission = "user:import"
+	PermUserPurge       Permission = "user:purge"
+)
+
+// ownSuffix restricts a granted permission to the caller's own record
+const ownSuffix = ":own"
+
+var permissions = []Permission{
+	PermUserRead, PermUserList, PermUserListDeleted, PermUserCreate, PermUserUpdate, PermUserDelete,
+	PermUserRestore, PermUserHistory, PermUserImport, PermUserPurge,
+}
+
+// ErrForbidden matches every PermissionError
+var ErrForbidden = errors.New("permission denied")
+
+// PermissionError names the permission the caller is missing
+type PermissionError struct {
+	Permission Permission
+}
+
+func (e *PermissionError) Error() string {
+	return "missing permission " + string(e.Permission)
+}
+
+func (e *PermissionError) Is(target error) bool {
+	return target == ErrForbidden
+}
+
+// DefaultRoles are the roles available without configuration. API keys get
+// the role named after each of their scopes.
+func DefaultRoles() map[string][]string {
+	return map[string][]string{
+		"admin":   {"user:*"},
+		"support": {"user:read", "user:list", "user:update", "user:history"},
+		"user":    {"user:read:own", "user:update:own", "user:history:own"},
+
+		string(ScopeUsersRead):  {"user:read", "user:list", "user:history"},
+		string(ScopeUsersWrite): {"user:read", "user:list", "user:history", "user:create", "user:update", "user:delete"},
+		string(ScopeUsersAdmin): {"user:*"},
+	}
+}
+
+// grant is a permission held through a role
+type grant struct {
+	permission Permission
+	ownOnly    bool
+}
+
+// Policy maps roles to permissions and decides whether a principal may
+// perform an operation
+type Policy struct {
+	roles map[string][]grant
+}
+
+// NewPolicy builds a policy from DefaultRoles overridden by roles. Each
+// permission is a Permission, optionally followed by ":own", or "user:*" for
+// all of them.
+func NewPolicy(roles map[string][]string) (*Policy, error) {
+	merged := DefaultRoles()
+	maps.Copy(merged, roles)
+
+	p := &Policy{roles: make(map[string][]grant, len(merged))}
+	var errs []error
+	for _, role := range slices.Sorted(maps.Keys(merged)) {
+		for _, name := range merged[role] {
+			if name == "user:*" {
+				for _, perm := range permissions {
+					p.roles[role] = append(p.roles[role], grant{permission: perm})
+				}
+				continue
+			}
+			perm, ownOnly := strings.CutSuffix(name, ownSuffix)
+			if !slices.Contains(permissions, Permission(perm)) {
+				errs = append(errs, fmt.Errorf("role %q: unknown permission %q", role, name))
+				continue
+			}
+			p.roles[role] = append(p.roles[role], grant{permission: Permission(perm), ownOnly: ownOnly})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return p, nil
+}
+
+// Authorize checks that the principal in ctx holds perm. ownerID is the ID of
+// the user the operation targets, or 0 when there is none (e.g. list); only
+// then can a ":own" grant apply. A missing principal is denied, except for
+// the System principal which is allowed everything.
+func (p *Policy) Authorize(ctx context.Context, perm Permission, ownerID int32) error {
+	principal, ok := FromContext(ctx)
+	if ok && principal.System {
+		return nil
+	}
+	if ok {
+		for _, role := range principal.Roles {
+			for _, g := range p.roles[role] {
+				if g.permission != perm {
+					continue
+				}
+				if !g.ownOnly || ownerID != 0 && ownerID == principal.UserID {
+					return nil
+				}
+			}
+		}
+	}
+	return &PermissionError{Permission: perm}
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyAuthorize(t *testing.T) {
+	policy, err := NewPolicy(map[string][]string{
+		"auditor": {"user:history"},
+	})
+	assert.NoError(t, err)
+
+	as := func(p Principal) context.Context {
+		return WithPrincipal(context.Background(), p)
+	}
+	support := as(Principal{Subject: "alice", Roles: []string{"support"}})
+	owner := as(Principal{Subject: "bob", Roles: []string{"user"}, UserID: 7})
+	auditor := as(Principal{Subject: "carol", Roles: []string{"auditor"}})
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		perm    Permission
+		ownerID int32
+		allowed bool
+	}{
+		{name: "Support reads", ctx: support, perm: PermUserRead, ownerID: 1, allowed: true},
+		{name: "Support updates", ctx: support, perm: PermUserUpdate, ownerID: 1, allowed: true},
+		{name: "Support cannot delete", ctx: support, perm: PermUserDelete, ownerID: 1},
+		{name: "Owner reads own record", ctx: owner, perm: PermUserRead, ownerID: 7, allowed: true},
+		{name: "Owner cannot read others", ctx: owner, perm: PermUserRead, ownerID: 8},
+		{name: "Owner cannot list", ctx: owner, perm: PermUserList},
+		{name: "Configured role", ctx: auditor, perm: PermUserHistory, ownerID: 3, allowed: true},
+		{name: "Configured role is limited", ctx: auditor, perm: PermUserRead, ownerID: 3},
+		{name: "Read scope cannot list deleted users", ctx: as(Principal{Roles: []string{"users:read"}}), perm: PermUserListDeleted},
+		{name: "Admin scope lists deleted users", ctx: as(Principal{Roles: []string{"users:admin"}}), perm: PermUserListDeleted, allowed: true},
+		{name: "API key scope role", ctx: as(Principal{Roles: []string{"users:admin"}}), perm: PermUserImport, allowed: true},
+		{name: "System", ctx: as(SystemPrincipal), perm: PermUserPurge, allowed: true},
+		{name: "No principal", ctx: context.Background(), perm: PermUserRead, ownerID: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Authorize(tt.ctx, tt.perm, tt.ownerID)
+			if tt.allowed {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrForbidden)
+			var permErr *PermissionError
+			assert.True(t, errors.As(err, &permErr))
+			assert.Equal(t, tt.perm, permErr.Permission)
+		})
+	}
+}
+
+func TestNewPolicyRejectsUnknownPermissions(t *testing.T) {
+	_, err := NewPolicy(map[string][]string{"support": {"user:read", "user:fly:own"}})
+	assert.ErrorContains(t, err, `role "support": unknown permission "user:fly:own"`)
+}
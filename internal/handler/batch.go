@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/service"
+	"go.uber.org/zap"
+)
+
+// batchStatus is the per-item status of a successful operation, matching the
+// status of the equivalent single-user endpoint
+var batchStatus = map[string]int{
+	models.BatchOpCreate: fiber.StatusCreated,
+	models.BatchOpUpdate: fiber.StatusOK,
+	models.BatchOpDelete: fiber.StatusNoContent,
+}
+
+func (h *UserHandler) BatchUsers(c *fiber.Ctx) error {
+	var req models.BatchRequest
+
+	// Parse request body
+	if err := c.BodyParser(&req); err != nil {
+		logger.FromContext(c.UserContext()).Error("Failed to parse request body", zap.Error(err))
+		return errInvalidBody
+	}
+
+	// Validate the envelope
+	if err := h.validate.Struct(req); err != nil {
+		logger.FromContext(c.UserContext()).Error("Validation failed", zap.Error(err))
+		return err
+	}
+	if req.Mode == "" {
+		req.Mode = models.BatchModeAtomic
+	}
+
+	// Validate each operation on its own so invalid operations are reported
+	// per item rather than failing the whole request; the service enforces
+	// the batch limit and aborts atomic batches with invalid operations
+	ops := make([]models.BatchOperation, len(req.Operations))
+	for i, item := range req.Operations {
+		ops[i] = toBatchOperation(item)
+		ops[i].Invalid = h.validate.Struct(item)
+	}
+
+	results, err := h.service.BatchUsers(c.UserContext(), ops, req.Mode == models.BatchModeAtomic)
+	if err != nil {
+		logger.FromContext(c.UserContext()).Error("Failed to apply batch", zap.Error(err))
+		return err
+	}
+
+	response, status := newBatchResponse(req, results)
+
+	logger.FromContext(c.UserContext()).Info("Batch applied",
+		zap.String("mode", req.Mode),
+		zap.Int("succeeded", response.Succeeded),
+		zap.Int("failed", response.Failed),
+	)
+
+	return c.Status(status).JSON(response)
+}
+
+// toBatchOperation converts a batch operation for the service layer
+func toBatchOperation(item models.BatchOperationRequest) models.BatchOperation {
+	op := models.BatchOperation{
+		Op: item.Op,
+		ID: item.ID,
+	}
+	if item.User != nil {
+		op.Name = item.User.Name
+		op.DOB = item.User.DOB
+	}
+	if item.IfMatch != nil {
+		op.IfMatch = []int32{*item.IfMatch}
+	}
+	return op
+}
+
+// newBatchResponse builds the per-item results and the response status:
+// 200 when every operation succeeded, 207 when a best-effort batch partially
+// failed, and the status of the failing operation when an atomic batch failed
+func newBatchResponse(req models.BatchRequest, results []service.BatchResult) (models.BatchResponse, int) {
+	response := models.BatchResponse{
+		Mode:    req.Mode,
+		Results: make([]models.BatchItemResult, 0, len(results)),
+	}
+	status := fiber.StatusOK
+
+	for i, result := range results {
+		op := req.Operations[i].Op
+		item := models.BatchItemResult{Index: i, Op: op}
+
+		if result.Err != nil {
+			problem := newProblem(result.Err)
+			item.Status = problem.Status
+			item.Error = &problem
+			response.Failed++
+
+			if status == fiber.StatusOK {
+				status = fiber.StatusMultiStatus
+			}
+			if req.Mode == models.BatchModeAtomic && status == fiber.StatusMultiStatus &&
+				problem.Status != fiber.StatusFailedDependency {
+				status = problem.Status
+			}
+		} else {
+			item.Status = batchStatus[op]
+			if op != models.BatchOpDelete {
+				item.User = &models.UserResponse{
+					ID:   result.User.ID,
+					Name: result.User.Name,
+					DOB:  result.User.Dob.Time.Format("2006-01-02"),
+				}
+			}
+			response.Succeeded++
+		}
+
+		response.Results = append(response.Results, item)
+	}
+
+	response.Committed = response.Succeeded > 0
+	return response, status
+}
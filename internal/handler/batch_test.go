@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// batchService applies every valid operation it receives, unless the batch
+// is atomic and some are invalid
+type batchService struct {
+	service.UserService
+	ops     []models.BatchOperation
+	applied []models.BatchOperation
+}
+
+func (m *batchService) BatchUsers(ctx context.Context, ops []models.BatchOperation, atomic bool) ([]service.BatchResult, error) {
+	m.ops = ops
+	aborted := atomic && slices.ContainsFunc(ops, func(op models.BatchOperation) bool { return op.Invalid != nil })
+	results := make([]service.BatchResult, len(ops))
+	for i, op := range ops {
+		switch {
+		case op.Invalid != nil:
+			results[i].Err = op.Invalid
+		case aborted:
+			results[i].Err = service.ErrBatchAborted
+		default:
+			results[i].User = db.User{ID: op.ID, Name: op.Name}
+			m.applied = append(m.applied, op)
+		}
+	}
+	return results, nil
+}
+
+func sendBatch(t *testing.T, svc service.UserService, body string) (int, models.BatchResponse) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Post("/users\\:batch", NewUserHandler(svc).BatchUsers)
+
+	req := httptest.NewRequest(fiber.MethodPost, "/users:batch", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+
+	var response models.BatchResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	return resp.StatusCode, response
+}
+
+func TestBatchUsersBestEffort(t *testing.T) {
+	svc := &batchService{}
+	status, response := sendBatch(t, svc, `{"mode":"best_effort","operations":[
+		{"op":"create","user":{"name":"Alice","dob":"1990-05-10"}},
+		{"op":"create","user":{"name":"A","dob":"1990-05-10"}},
+		{"op":"delete","id":7,"if_match":2}
+	]}`)
+
+	assert.Equal(t, fiber.StatusMultiStatus, status)
+	assert.Equal(t, []models.BatchOperation{
+		{Op: models.BatchOpCreate, Name: "Alice", DOB: "1990-05-10"},
+		{Op: models.BatchOpDelete, ID: 7, IfMatch: []int32{2}},
+	}, svc.applied)
+	assert.Len(t, svc.ops, 3)
+	assert.Error(t, svc.ops[1].Invalid)
+	assert.True(t, response.Committed)
+	assert.Equal(t, 2, response.Succeeded)
+	assert.Equal(t, 1, response.Failed)
+	assert.Equal(t, fiber.StatusCreated, response.Results[0].Status)
+	assert.Equal(t, "Alice", response.Results[0].User.Name)
+	assert.Equal(t, fiber.StatusBadRequest, response.Results[1].Status)
+	assert.Equal(t, "user.name", response.Results[1].Error.Errors[0].Field)
+	assert.Equal(t, fiber.StatusNoContent, response.Results[2].Status)
+	assert.Nil(t, response.Results[2].User)
+}
+
+func TestBatchUsersAtomicValidation(t *testing.T) {
+	svc := &batchService{}
+	status, response := sendBatch(t, svc, `{"operations":[
+		{"op":"create","user":{"name":"Alice","dob":"1990-05-10"}},
+		{"op":"update","user":{"name":"Bob","dob":"1990-05-10"}}
+	]}`)
+
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Nil(t, svc.applied)
+	assert.Equal(t, models.BatchModeAtomic, response.Mode)
+	assert.False(t, response.Committed)
+	assert.Equal(t, fiber.StatusFailedDependency, response.Results[0].Status)
+	assert.Equal(t, "id", response.Results[1].Error.Errors[0].Field)
+}
+
+func TestBatchUsersLimit(t *testing.T) {
+	// The service enforces the limit before touching its repository
+	svc := service.NewUserService(nil, service.WithBatchLimit(2))
+
+	// Invalid operations count towards the limit, even in best-effort mode
+	status, _ := sendBatch(t, svc, `{"mode":"best_effort","operations":[
+		{"op":"create","user":{"name":"Alice","dob":"1990-05-10"}},
+		{"op":"create","user":{"name":"A","dob":"1990-05-10"}},
+		{"op":"delete"}
+	]}`)
+
+	assert.Equal(t, fiber.StatusBadRequest, status)
+}
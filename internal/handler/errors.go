@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/rohanparmar/go-user-api/internal/middleware"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/service"
+	"go.uber.org/zap"
+)
+
+// MIMEApplicationProblemJSON is the RFC 7807 media type used for error responses
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Problem type URIs. Generic HTTP errors use "about:blank" as per RFC 7807.
+const (
+	problemTypeBlank        = "about:blank"
+	problemTypeValidation   = "/problems/validation-error"
+	problemTypeNotFound     = "/problems/not-found"
+	problemTypeConflict     = "/problems/conflict"
+	problemTypePrecondition = "/problems/precondition-failed"
+	problemTypeUnavailable  = "/problems/service-unavailable"
+	problemTypeBatchAborted = "/problems/batch-aborted"
+	problemTypeForbidden    = "/problems/forbidden"
+	problemTypeQuota        = "/problems/quota-exceeded"
+	problemTypeInternal     = "/problems/internal-error"
+)
+
+// Errors returned by handlers for malformed requests
+var (
+	errInvalidBody   = fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	errInvalidUserID = fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	errInvalidAsOf   = fiber.NewError(fiber.StatusBadRequest, "Invalid as_of timestamp, use RFC 3339")
+)
+
+// ErrorHandler is the single place where errors returned by handlers are
+// mapped to HTTP status codes. It is installed as the Fiber ErrorHandler and
+// renders every error as application/problem+json.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	problem := newProblem(err)
+	problem.Instance = c.OriginalURL()
+	problem.RequestID = middleware.GetRequestID(c)
+
+	if problem.Status >= fiber.StatusInternalServerError {
+		logger.FromContext(c.UserContext()).Error("Request error",
+			zap.Int("status", problem.Status),
+			zap.Error(err),
+		)
+	}
+
+	if problem.Status == fiber.StatusServiceUnavailable {
+		c.Set(fiber.HeaderRetryAfter, "5")
+	}
+
+	return c.Status(problem.Status).JSON(problem, MIMEApplicationProblemJSON)
+}
+
+// newProblem converts an error into problem details
+func newProblem(err error) models.ProblemDetails {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return models.ProblemDetails{
+			Type:   problemTypeBlank,
+			Title:  fiberErr.Message,
+			Status: fiberErr.Code,
+		}
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return models.ProblemDetails{
+			Type:   problemTypeValidation,
+			Title:  "Validation failed",
+			Status: fiber.StatusBadRequest,
+			Detail: "One or more fields are invalid",
+			Errors: fieldErrors(validationErrs),
+		}
+	}
+
+	var businessErr *service.ValidationError
+	if errors.As(err, &businessErr) {
+		problem := models.ProblemDetails{
+			Type:   problemTypeValidation,
+			Title:  "Validation failed",
+			Status: fiber.StatusBadRequest,
+			Detail: businessErr.Message,
+		}
+		if businessErr.Field != "" {
+			problem.Errors = []models.FieldError{{
+				Field:   businessErr.Field,
+				Message: businessErr.Message,
+			}}
+		}
+		return problem
+	}
+
+	var permissionErr *service.PermissionError
+	if errors.As(err, &permissionErr) {
+		return models.ProblemDetails{
+			Type:              problemTypeForbidden,
+			Title:             "Forbidden",
+			Status:            fiber.StatusForbidden,
+			Detail:            "Missing permission " + string(permissionErr.Permission),
+			MissingPermission: string(permissionErr.Permission),
+		}
+	}
+
+	var quotaErr *service.QuotaError
+	if errors.As(err, &quotaErr) {
+		return models.ProblemDetails{
+			Type:   problemTypeQuota,
+			Title:  "User quota exceeded",
+			Status: fiber.StatusForbidden,
+			Detail: quotaErr.Error(),
+		}
+	}
+
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		return models.ProblemDetails{
+			Type:   problemTypeNotFound,
+			Title:  "User not found",
+			Status: fiber.StatusNotFound,
+		}
+	case errors.Is(err, service.ErrConflict):
+		return models.ProblemDetails{
+			Type:   problemTypeConflict,
+			Title:  "User conflicts with existing data",
+			Status: fiber.StatusConflict,
+			Detail: conflictDetail(err),
+		}
+	case errors.Is(err, service.ErrPreconditionFailed):
+		return models.ProblemDetails{
+			Type:   problemTypePrecondition,
+			Title:  "User has been modified",
+			Status: fiber.StatusPreconditionFailed,
+			Detail: "The If-Match header does not match the current ETag; fetch the user again and retry",
+		}
+	case errors.Is(err, service.ErrBatchAborted):
+		return models.ProblemDetails{
+			Type:   problemTypeBatchAborted,
+			Title:  "Operation not applied",
+			Status: fiber.StatusFailedDependency,
+			Detail: "Another operation in the atomic batch failed, so the whole batch was rolled back",
+		}
+	case errors.Is(err, service.ErrUnavailable):
+		return models.ProblemDetails{
+			Type:   problemTypeUnavailable,
+			Title:  "Service temporarily unavailable",
+			Status: fiber.StatusServiceUnavailable,
+		}
+	default:
+		return models.ProblemDetails{
+			Type:   problemTypeInternal,
+			Title:  "Internal server error",
+			Status: fiber.StatusInternalServerError,
+		}
+	}
+}
+
+// conflictDetail returns the reason wrapped around ErrConflict by the service,
+// e.g. "user 5 is not deleted". Conflicts reported by the database are left
+// without detail so that driver messages are not exposed.
+func conflictDetail(err error) string {
+	if errors.Unwrap(err) != service.ErrConflict {
+		return ""
+	}
+	return strings.TrimPrefix(err.Error(), service.ErrConflict.Error()+": ")
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/auth"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/service"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	m.Run()
+}
+
+func TestErrorHandlerValidationProblem(t *testing.T) {
+	h := NewUserHandler(nil)
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Post("/users", h.CreateUser)
+
+	req := httptest.NewRequest(fiber.MethodPost, "/users", strings.NewReader(`{"name":"A"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, MIMEApplicationProblemJSON, resp.Header.Get(fiber.HeaderContentType))
+
+	var problem models.ProblemDetails
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, problemTypeValidation, problem.Type)
+	assert.Equal(t, []models.FieldError{
+		{Field: "name", Message: "must be at least 2 characters long", Rule: "min"},
+		{Field: "dob", Message: "is required", Rule: "required"},
+	}, problem.Errors)
+}
+
+func TestNewProblemStatusMapping(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "Not found", err: service.ErrNotFound, status: fiber.StatusNotFound},
+		{name: "Conflict", err: service.ErrConflict, status: fiber.StatusConflict},
+		{name: "Unavailable", err: service.ErrUnavailable, status: fiber.StatusServiceUnavailable},
+		{name: "Business validation", err: service.NewValidationError("dob", "bad"), status: fiber.StatusBadRequest},
+		{name: "Forbidden", err: &service.PermissionError{Permission: auth.PermUserDelete}, status: fiber.StatusForbidden},
+		{name: "Quota exceeded", err: &service.QuotaError{Tenant: "acme", Limit: 10}, status: fiber.StatusForbidden},
+		{name: "Fiber error", err: fiber.ErrMethodNotAllowed, status: fiber.StatusMethodNotAllowed},
+		{name: "Unknown", err: assert.AnError, status: fiber.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.status, newProblem(tt.err).Status)
+		})
+	}
+	problem := newProblem(&service.PermissionError{Permission: auth.PermUserDelete})
+	assert.Equal(t, "user:delete", problem.MissingPermission)
+	assert.Equal(t, "Missing permission user:delete", problem.Detail)
+}
+
+func TestNewProblemConflictDetail(t *testing.T) {
+	problem := newProblem(fmt.Errorf("%w: patch test operation 2 failed", service.ErrConflict))
+	assert.Equal(t, fiber.StatusConflict, problem.Status)
+	assert.Equal(t, "patch test operation 2 failed", problem.Detail)
+
+	problem = newProblem(fmt.Errorf("%w: user 5 is not deleted", service.ErrConflict))
+	assert.Equal(t, "user 5 is not deleted", problem.Detail)
+
+	// Conflicts reported by the database do not expose the driver message
+	assert.Empty(t, newProblem(driverConflict{}).Detail)
+	assert.Empty(t, newProblem(service.ErrConflict).Detail)
+}
+
+// driverConflict mimics a unique violation translated by the repository
+type driverConflict struct{}
+
+func (driverConflict) Error() string {
+	return "record conflicts with existing data: ERROR: duplicate key value (SQLSTATE 23505)"
+}
+
+func (driverConflict) Is(target error) bool { return target == service.ErrConflict }
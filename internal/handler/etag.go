@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+)
+
+// userETag returns the strong entity tag of a user, derived from its row version
+func userETag(user db.User) string {
+	return fmt.Sprintf("%q", strconv.Itoa(int(user.Version)))
+}
+
+// parseIfMatch returns the row versions listed in the If-Match header.
+// It returns nil when the header is absent or "*" (no version check) and a
+// non-nil, possibly empty, slice otherwise. Weak tags never match because
+// If-Match requires the strong comparison function (RFC 9110 13.1.1).
+func parseIfMatch(c *fiber.Ctx) []int32 {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" || header == "*" {
+		return nil
+	}
+
+	versions := []int32{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		version, err := strconv.ParseInt(strings.Trim(tag, `"`), 10, 32)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, int32(version))
+	}
+	return versions
+}
+
+// ifNoneMatch reports whether the If-None-Match header matches etag using the
+// weak comparison function (RFC 9110 13.1.2)
+func ifNoneMatch(c *fiber.Ctx, etag string) bool {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfNoneMatch))
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
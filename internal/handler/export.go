@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/rohanparmar/go-user-api/internal/middleware"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/parquet"
+	"github.com/rohanparmar/go-user-api/internal/service"
+	"go.uber.org/zap"
+)
+
+// userEncoder writes exported users in one file format
+type userEncoder interface {
+	Encode(user models.UserResponse) error
+	Close() error
+}
+
+// exportFormat describes a format accepted by GET /users/export?format=
+type exportFormat struct {
+	contentType string
+	newEncoder  func(w io.Writer) (userEncoder, error)
+}
+
+var exportFormats = map[string]exportFormat{
+	"csv":     {contentType: "text/csv; charset=utf-8", newEncoder: newCSVEncoder},
+	"ndjson":  {contentType: MIMEApplicationNDJSON, newEncoder: newNDJSONEncoder},
+	"parquet": {contentType: "application/vnd.apache.parquet", newEncoder: newParquetEncoder},
+}
+
+func (h *UserHandler) ExportUsers(c *fiber.Ctx) error {
+	name := c.Query("format", "csv")
+	format, ok := exportFormats[name]
+	if !ok {
+		return service.NewValidationError("format", "must be one of: csv ndjson parquet")
+	}
+
+	query, err := parseListQuery(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate the query before the response is committed to a 200
+	export, err := h.service.ExportUsers(c.UserContext(), query)
+	if err != nil {
+		logger.FromContext(c.UserContext()).Error("Invalid export query", zap.Error(err))
+		return err
+	}
+
+	c.Attachment(fmt.Sprintf("users-%s.%s", time.Now().UTC().Format("20060102T150405Z"), name))
+	c.Set(fiber.HeaderContentType, format.contentType)
+
+	// The stream writer runs after the handler returns, so it must not touch c
+	ctx := c.UserContext()
+	middleware.StreamBody(c, func(w *bufio.Writer) {
+		count, err := writeExport(ctx, w, format, export)
+		if err != nil {
+			// Headers are already sent; the client sees a truncated file
+			logger.FromContext(ctx).Error("Export aborted", zap.String("format", name), zap.Int("rows", count), zap.Error(err))
+			return
+		}
+		logger.FromContext(ctx).Info("Users exported", zap.String("format", name), zap.Int("rows", count))
+	})
+	return nil
+}
+
+// writeExport runs export and encodes every user to w
+func writeExport(ctx context.Context, w *bufio.Writer, format exportFormat, export service.UserExport) (int, error) {
+	encoder, err := format.newEncoder(w)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = export(ctx, func(user models.UserResponse) error {
+		count++
+		return encoder.Encode(user)
+	})
+	if err != nil {
+		return count, err
+	}
+	if err := encoder.Close(); err != nil {
+		return count, err
+	}
+	return count, w.Flush()
+}
+
+// csvEncoder writes a header row followed by one row per user
+type csvEncoder struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVEncoder(w io.Writer) (userEncoder, error) {
+	e := &csvEncoder{w: csv.NewWriter(w), record: make([]string, 5)}
+	return e, e.w.Write([]string{"id", "name", "dob", "age", "deleted_at"})
+}
+
+func (e *csvEncoder) Encode(user models.UserResponse) error {
+	e.record[0] = strconv.Itoa(int(user.ID))
+	e.record[1] = user.Name
+	e.record[2] = user.DOB
+	e.record[3] = ""
+	if user.Age != nil {
+		e.record[3] = strconv.Itoa(*user.Age)
+	}
+	e.record[4] = ""
+	if user.DeletedAt != nil {
+		e.record[4] = *user.DeletedAt
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// ndjsonEncoder writes one JSON object per line, as returned by GET /users
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func newNDJSONEncoder(w io.Writer) (userEncoder, error) {
+	return &ndjsonEncoder{enc: json.NewEncoder(w)}, nil
+}
+
+func (e *ndjsonEncoder) Encode(user models.UserResponse) error {
+	return e.enc.Encode(user)
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+// parquetColumns is the Parquet schema of an exported user
+var parquetColumns = []parquet.Column{
+	{Name: "id", Type: parquet.Int32},
+	{Name: "name", Type: parquet.ByteArray, Converted: parquet.UTF8},
+	{Name: "dob", Type: parquet.Int32, Converted: parquet.Date},
+	{Name: "age", Type: parquet.Int32},
+	{Name: "deleted_at", Type: parquet.Int64, Converted: parquet.TimestampMillis, Optional: true},
+}
+
+// parquetEncoder writes users as typed Parquet columns
+type parquetEncoder struct {
+	pw *parquet.Writer
+}
+
+func newParquetEncoder(w io.Writer) (userEncoder, error) {
+	pw, err := parquet.NewWriter(w, parquetColumns)
+	if err != nil {
+		return nil, err
+	}
+	return &parquetEncoder{pw: pw}, nil
+}
+
+func (e *parquetEncoder) Encode(user models.UserResponse) error {
+	dob, err := time.Parse("2006-01-02", user.DOB)
+	if err != nil {
+		return err
+	}
+	var age int32
+	if user.Age != nil {
+		age = int32(*user.Age)
+	}
+	var deletedAt any
+	if user.DeletedAt != nil {
+		at, err := time.Parse(time.RFC3339, *user.DeletedAt)
+		if err != nil {
+			return err
+		}
+		deletedAt = at.UnixMilli()
+	}
+
+	days := int32(dob.Unix() / 86400) // days since the Unix epoch
+	return e.pw.Write(user.ID, user.Name, days, age, deletedAt)
+}
+
+func (e *parquetEncoder) Close() error {
+	return e.pw.Close()
+}
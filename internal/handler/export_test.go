@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// exportService exports two canned users
+type exportService struct {
+	service.UserService
+	query models.ListUsersQuery
+}
+
+func (m *exportService) ExportUsers(ctx context.Context, query models.ListUsersQuery) (service.UserExport, error) {
+	m.query = query
+	age := 36
+	deletedAt := "2026-01-02T03:04:05Z"
+	return func(ctx context.Context, fn func(models.UserResponse) error) error {
+		if err := fn(models.UserResponse{ID: 1, Name: "Alice", DOB: "1990-05-10", Age: &age}); err != nil {
+			return err
+		}
+		return fn(models.UserResponse{ID: 2, Name: "Smith, Bob", DOB: "1990-05-10", Age: &age, DeletedAt: &deletedAt})
+	}, nil
+}
+
+func exportUsers(t *testing.T, svc service.UserService, target string) (string, int, string) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Get("/users/export", NewUserHandler(svc).ExportUsers)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, target, nil))
+	assert.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return resp.Header.Get(fiber.HeaderContentDisposition), resp.StatusCode, string(body)
+}
+
+func TestExportUsersCSV(t *testing.T) {
+	svc := &exportService{}
+	disposition, status, body := exportUsers(t, svc, "/users/export?name_prefix=Al&include_deleted=true")
+
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Regexp(t, `^attachment; filename="users-\d{8}T\d{6}Z\.csv"$`, disposition)
+	assert.Equal(t, "Al", svc.query.NamePrefix)
+	assert.True(t, svc.query.IncludeDeleted)
+	assert.Equal(t, "id,name,dob,age,deleted_at\n"+
+		"1,Alice,1990-05-10,36,\n"+
+		"2,\"Smith, Bob\",1990-05-10,36,2026-01-02T03:04:05Z\n", body)
+}
+
+func TestExportUsersFormats(t *testing.T) {
+	_, status, body := exportUsers(t, &exportService{}, "/users/export?format=ndjson")
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, `{"id":1,"name":"Alice","dob":"1990-05-10","age":36}`+"\n"+
+		`{"id":2,"name":"Smith, Bob","dob":"1990-05-10","age":36,"deleted_at":"2026-01-02T03:04:05Z"}`+"\n", body)
+
+	disposition, status, body := exportUsers(t, &exportService{}, "/users/export?format=parquet")
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Contains(t, disposition, ".parquet")
+	assert.Equal(t, "PAR1", body[:4])
+	assert.Equal(t, "PAR1", body[len(body)-4:])
+
+	_, status, _ = exportUsers(t, &exportService{}, "/users/export?format=xlsx")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/health"
+	"github.com/rohanparmar/go-user-api/internal/models"
+)
+
+// HealthHandler serves the orchestrator probes.
+// /healthz only reports that the process is up; /readyz runs the readiness
+// checks and fails once shutdown has started.
+type HealthHandler struct {
+	readiness *health.Readiness
+	checks    []health.Check
+}
+
+func NewHealthHandler(readiness *health.Readiness, checks ...health.Check) *HealthHandler {
+	return &HealthHandler{
+		readiness: readiness,
+		checks:    checks,
+	}
+}
+
+func (h *HealthHandler) Liveness(c *fiber.Ctx) error {
+	return c.JSON(models.HealthResponse{Status: models.HealthStatusOK})
+}
+
+func (h *HealthHandler) Readiness(c *fiber.Ctx) error {
+	// Probes must never be served from a cache
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	if h.readiness.Draining() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(models.HealthResponse{
+			Status: models.HealthStatusDraining,
+		})
+	}
+
+	results, healthy := health.RunChecks(c.UserContext(), h.checks)
+	response := models.HealthResponse{Status: models.HealthStatusOK, Checks: results}
+	if !healthy {
+		response.Status = models.HealthStatusFailing
+		return c.Status(fiber.StatusServiceUnavailable).JSON(response)
+	}
+	return c.JSON(response)
+}
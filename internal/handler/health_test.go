@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/health"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func probe(t *testing.T, h *HealthHandler, path string) (int, models.HealthResponse) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Get("/healthz", h.Liveness)
+	app.Get("/readyz", h.Readiness)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+	assert.NoError(t, err)
+
+	var response models.HealthResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	return resp.StatusCode, response
+}
+
+func passingCheck(name string) health.Check {
+	return health.Check{Name: name, Run: func(ctx context.Context) (any, error) {
+		return map[string]int{"version": 3}, nil
+	}}
+}
+
+func TestReadinessAllChecksPass(t *testing.T) {
+	h := NewHealthHandler(&health.Readiness{}, passingCheck("database"), passingCheck("migrations"))
+
+	status, response := probe(t, h, "/readyz")
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, models.HealthStatusOK, response.Status)
+	assert.Len(t, response.Checks, 2)
+	assert.Equal(t, models.HealthStatusOK, response.Checks["database"].Status)
+}
+
+func TestReadinessFailingCheck(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	logger.Log = zap.New(core)
+	defer func() { logger.Log = zap.NewNop() }()
+
+	h := NewHealthHandler(&health.Readiness{},
+		passingCheck("migrations"),
+		health.Check{Name: "database", Run: func(ctx context.Context) (any, error) {
+			return nil, errors.New("connection refused")
+		}},
+	)
+
+	status, response := probe(t, h, "/readyz")
+	assert.Equal(t, fiber.StatusServiceUnavailable, status)
+	assert.Equal(t, models.HealthStatusFailing, response.Status)
+	assert.Equal(t, models.HealthStatusFailing, response.Checks["database"].Status)
+	assert.Equal(t, models.HealthStatusOK, response.Checks["migrations"].Status)
+
+	// The error is logged, never sent to the public endpoint
+	entries := logs.FilterField(zap.String("check", "database")).All()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "connection refused", entries[0].ContextMap()["error"])
+	}
+}
+
+func TestReadinessWhileDraining(t *testing.T) {
+	readiness := &health.Readiness{}
+	readiness.Drain()
+	h := NewHealthHandler(readiness, passingCheck("database"))
+
+	status, response := probe(t, h, "/readyz")
+	assert.Equal(t, fiber.StatusServiceUnavailable, status)
+	assert.Equal(t, models.HealthStatusDraining, response.Status)
+
+	// The process is still alive while it drains
+	status, response = probe(t, h, "/healthz")
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, models.HealthStatusOK, response.Status)
+}
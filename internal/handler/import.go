@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/service"
+	"go.uber.org/zap"
+)
+
+// Media types accepted by POST /users/import
+const (
+	MIMETextCSV           = "text/csv"
+	MIMEApplicationNDJSON = "application/x-ndjson"
+)
+
+// maxNDJSONLine is the longest NDJSON line accepted by an import
+const maxNDJSONLine = 1 << 20
+
+// DefaultImportLimit is the largest import file accepted unless
+// WithImportLimit says otherwise
+const DefaultImportLimit = 1 << 30
+
+var (
+	errInvalidImport  = fiber.NewError(fiber.StatusBadRequest, "Invalid import file")
+	errImportTooLarge = fiber.NewError(fiber.StatusRequestEntityTooLarge, "Import file too large")
+)
+
+func (h *UserHandler) ImportUsers(c *fiber.Ctx) error {
+	opts := models.ImportOptions{
+		DryRun:     c.QueryBool("dry_run", false),
+		Duplicates: c.Query("on_duplicate"),
+	}
+
+	contentType := string(c.Request().Header.ContentType())
+	isCSV := hasMediaType(contentType, MIMETextCSV)
+	if !isCSV && !hasMediaType(contentType, MIMEApplicationNDJSON) {
+		c.Set(fiber.HeaderAccept, MIMETextCSV+", "+MIMEApplicationNDJSON)
+		return fiber.ErrUnsupportedMediaType
+	}
+
+	// Stage the upload in a temporary file, so large files are never held in
+	// memory and a slow client does not hold a transaction open
+	body, err := h.stageImport(c)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		body.Close()
+		os.Remove(body.Name())
+	}()
+
+	var src service.ImportSource
+	if isCSV {
+		csvSrc, err := newCSVSource(body, h.validate)
+		if err != nil {
+			logger.FromContext(c.UserContext()).Error("Invalid CSV header", zap.Error(err))
+			return err
+		}
+		src = csvSrc
+	} else {
+		src = newNDJSONSource(body, h.validate)
+	}
+
+	// Import users
+	report, err := h.service.ImportUsers(c.UserContext(), src, opts)
+	if err != nil {
+		if readErr := src.Err(); readErr != nil {
+			logger.FromContext(c.UserContext()).Error("Failed to read import file", zap.Error(readErr))
+			return errInvalidImport
+		}
+		logger.FromContext(c.UserContext()).Error("Failed to import users", zap.Error(err))
+		return err
+	}
+
+	logger.FromContext(c.UserContext()).Info("Users imported",
+		zap.Bool("dry_run", report.DryRun),
+		zap.Int("rows", report.Rows),
+		zap.Int64("imported", report.Imported),
+		zap.Int("failed", report.Failed),
+	)
+
+	status := fiber.StatusOK
+	switch {
+	case report.Failed > 0:
+		status = fiber.StatusUnprocessableEntity
+	case report.Committed:
+		status = fiber.StatusCreated
+	}
+	return c.Status(status).JSON(report)
+}
+
+// stageImport copies the request body, up to h.importLimit bytes, to a
+// temporary file rewound for reading. The caller closes and removes the file.
+func (h *UserHandler) stageImport(c *fiber.Ctx) (*os.File, error) {
+	body := c.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+
+	file, err := os.CreateTemp("", "user-import-*")
+	if err != nil {
+		logger.FromContext(c.UserContext()).Error("Failed to stage import file", zap.Error(err))
+		return nil, err
+	}
+
+	// Read one byte past the limit to tell a file of exactly the limit from a larger one
+	n, err := io.Copy(file, io.LimitReader(body, h.importLimit+1))
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	switch {
+	case err != nil:
+		logger.FromContext(c.UserContext()).Error("Failed to stage import file", zap.Error(err))
+		err = errInvalidImport
+	case n > h.importLimit:
+		err = errImportTooLarge
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return file, nil
+}
+
+// newImportRow validates a parsed row with the CreateUserRequest rules
+func newImportRow(validate *validator.Validate, line int, req models.CreateUserRequest) models.ImportRow {
+	req.Name = strings.TrimSpace(req.Name)
+	req.DOB = strings.TrimSpace(req.DOB)
+
+	row := models.ImportRow{Line: line, Name: req.Name, DOB: req.DOB}
+	var validationErrs validator.ValidationErrors
+	if err := validate.Struct(req); errors.As(err, &validationErrs) {
+		fe := validationErrs[0]
+		row.Err = service.NewValidationError(fieldPath(fe), fieldMessage(fe))
+	} else if err != nil {
+		row.Err = err
+	}
+	return row
+}
+
+// csvSource reads users from a CSV file whose header names the name and dob
+// columns (in any order; other columns are ignored)
+type csvSource struct {
+	reader   *csv.Reader
+	validate *validator.Validate
+	name     int
+	dob      int
+	row      models.ImportRow
+	err      error
+}
+
+func newCSVSource(r io.Reader, validate *validator.Validate) (*csvSource, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, service.NewValidationError("header", "CSV file must start with a header row")
+	}
+
+	src := &csvSource{reader: reader, validate: validate, name: -1, dob: -1}
+	for i, column := range header {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))) {
+		case "name":
+			src.name = i
+		case "dob":
+			src.dob = i
+		}
+	}
+	if src.name < 0 || src.dob < 0 {
+		return nil, service.NewValidationError("header", "CSV header must contain name and dob columns")
+	}
+	return src, nil
+}
+
+func (s *csvSource) Next() bool {
+	for {
+		record, err := s.reader.Read()
+		if err == io.EOF {
+			return false
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			s.row = models.ImportRow{
+				Line: parseErr.StartLine,
+				Err:  service.NewValidationError("", "malformed CSV: "+parseErr.Err.Error()),
+			}
+			return true
+		}
+		if err != nil {
+			s.err = err
+			return false
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue // blank line
+		}
+
+		line, _ := s.reader.FieldPos(0)
+		s.row = newImportRow(s.validate, line, models.CreateUserRequest{
+			Name: csvField(record, s.name),
+			DOB:  csvField(record, s.dob),
+		})
+		return true
+	}
+}
+
+func (s *csvSource) Row() models.ImportRow {
+	return s.row
+}
+
+func (s *csvSource) Err() error {
+	return s.err
+}
+
+// csvField returns the i-th field of a record, or "" for short records
+func csvField(record []string, i int) string {
+	if i < len(record) {
+		return record[i]
+	}
+	return ""
+}
+
+// ndjsonSource reads users from newline-delimited JSON objects shaped like
+// CreateUserRequest
+type ndjsonSource struct {
+	scanner  *bufio.Scanner
+	validate *validator.Validate
+	line     int
+	row      models.ImportRow
+}
+
+func newNDJSONSource(r io.Reader, validate *validator.Validate) *ndjsonSource {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLine)
+	return &ndjsonSource{scanner: scanner, validate: validate}
+}
+
+func (s *ndjsonSource) Next() bool {
+	for s.scanner.Scan() {
+		s.line++
+		data := bytes.TrimSpace(s.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var req models.CreateUserRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			s.row = models.ImportRow{
+				Line: s.line,
+				Err:  service.NewValidationError("", "line is not a valid JSON object"),
+			}
+			return true
+		}
+		s.row = newImportRow(s.validate, s.line, req)
+		return true
+	}
+	return false
+}
+
+func (s *ndjsonSource) Row() models.ImportRow {
+	return s.row
+}
+
+func (s *ndjsonSource) Err() error {
+	return s.scanner.Err()
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// drain collects every row of an import source
+func drain(src service.ImportSource) []models.ImportRow {
+	var rows []models.ImportRow
+	for src.Next() {
+		rows = append(rows, src.Row())
+	}
+	return rows
+}
+
+func TestCSVSource(t *testing.T) {
+	file := "\ufeffDOB,email,Name\n" +
+		"1990-05-10,alice@example.com, Alice \n" +
+		"\n" +
+		"1991-02-03,bob@example.com,B\n" +
+		"1992-01-01\n" +
+		"1993-01-01,x,\"Eve\"il\"\n"
+
+	src, err := newCSVSource(strings.NewReader(file), newValidator())
+	assert.NoError(t, err)
+
+	rows := drain(src)
+	assert.NoError(t, src.Err())
+	assert.Len(t, rows, 4)
+	assert.Equal(t, models.ImportRow{Line: 2, Name: "Alice", DOB: "1990-05-10"}, rows[0])
+	assert.Equal(t, 4, rows[1].Line)
+	assert.EqualError(t, rows[1].Err, "must be at least 2 characters long")
+	assert.Equal(t, 5, rows[2].Line)
+	assert.EqualError(t, rows[2].Err, "is required")
+	assert.Equal(t, 6, rows[3].Line)
+	assert.ErrorIs(t, rows[3].Err, service.ErrValidation)
+
+	_, err = newCSVSource(strings.NewReader("name,birthday\n"), newValidator())
+	assert.ErrorIs(t, err, service.ErrValidation)
+}
+
+func TestNDJSONSource(t *testing.T) {
+	file := `{"name":"Alice","dob":"1990-05-10"}` + "\n" +
+		"\n" +
+		`{"name":"Bob"}` + "\n" +
+		`not json` + "\n"
+
+	src := newNDJSONSource(strings.NewReader(file), newValidator())
+	rows := drain(src)
+	assert.NoError(t, src.Err())
+	assert.Len(t, rows, 3)
+	assert.Equal(t, models.ImportRow{Line: 1, Name: "Alice", DOB: "1990-05-10"}, rows[0])
+	assert.Equal(t, 3, rows[1].Line)
+	assert.EqualError(t, rows[1].Err, "is required")
+	assert.Equal(t, 4, rows[2].Line)
+	assert.ErrorIs(t, rows[2].Err, service.ErrValidation)
+}
+
+// importService records the rows it is asked to import
+type importService struct {
+	service.UserService
+	rows []models.ImportRow
+}
+
+func (s *importService) ImportUsers(ctx context.Context, src service.ImportSource, opts models.ImportOptions) (models.ImportReport, error) {
+	s.rows = drain(src)
+	return models.ImportReport{Rows: len(s.rows)}, src.Err()
+}
+
+func TestImportLimit(t *testing.T) {
+	svc := &importService{}
+	app := fiber.New(fiber.Config{StreamRequestBody: true})
+	app.Post("/users/import", NewUserHandler(svc, WithImportLimit(64)).ImportUsers)
+
+	sendImport := func(body string) int {
+		req := httptest.NewRequest(fiber.MethodPost, "/users/import", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, MIMETextCSV)
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, sendImport("name,dob\nAlice,1990-05-10\n"))
+	assert.Len(t, svc.rows, 1)
+
+	svc.rows = nil
+	assert.Equal(t, fiber.StatusRequestEntityTooLarge, sendImport("name,dob\n"+strings.Repeat("Alice,1990-05-10\n", 4)))
+	assert.Nil(t, svc.rows)
+}
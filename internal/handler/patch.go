@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/service"
+)
+
+// Media types accepted by PATCH /users/:id
+const (
+	MIMEApplicationMergePatchJSON = "application/merge-patch+json" // RFC 7396
+	MIMEApplicationJSONPatchJSON  = "application/json-patch+json"  // RFC 6902
+)
+
+// acceptPatch is advertised in the Accept-Patch header (RFC 5789)
+const acceptPatch = MIMEApplicationMergePatchJSON + ", " + MIMEApplicationJSONPatchJSON
+
+// jsonPatchOperation is a single RFC 6902 operation
+type jsonPatchOperation struct {
+	Op    string           `json:"op"`
+	Path  string           `json:"path"`
+	From  string           `json:"from,omitempty"`
+	Value *json.RawMessage `json:"value,omitempty"`
+}
+
+// patchableFields maps JSON Pointer paths to the JSON field they address
+var patchableFields = map[string]string{
+	"/name": "name",
+	"/dob":  "dob",
+}
+
+// parseMergePatch converts an RFC 7396 merge patch document into a PatchUserRequest.
+// Both fields are NOT NULL, so removing a member (null) is rejected.
+func parseMergePatch(body []byte) (models.PatchUserRequest, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return models.PatchUserRequest{}, errInvalidBody
+	}
+
+	var req models.PatchUserRequest
+	for field, raw := range doc {
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			return req, service.NewValidationError(field, "cannot be removed")
+		}
+		value, err := decodePatchValue(field, raw)
+		if err != nil {
+			return req, err
+		}
+		setPatchField(&req, field, value)
+	}
+	return req, nil
+}
+
+// parseJSONPatch converts an RFC 6902 JSON Patch document into the fields to
+// set and the "test" preconditions to check.
+// Only add/replace (set a field) and test (precondition) are meaningful for
+// the user resource; operations that would remove a field are rejected.
+// Operations apply in order: a test of a field set earlier in the patch is
+// checked against that value, any other test against the stored user.
+func parseJSONPatch(body []byte) (models.PatchUserRequest, models.UserPatch, error) {
+	var ops []jsonPatchOperation
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return models.PatchUserRequest{}, models.UserPatch{}, errInvalidBody
+	}
+
+	var req models.PatchUserRequest
+	var tests models.UserPatch
+	for i, op := range ops {
+		field, ok := patchableFields[op.Path]
+		if !ok {
+			return req, tests, service.NewValidationError(op.Path, fmt.Sprintf("operation %d targets an unknown path", i))
+		}
+
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return req, tests, service.NewValidationError(field, fmt.Sprintf("operation %d is missing a value", i))
+			}
+			value, err := decodePatchValue(field, *op.Value)
+			if err != nil {
+				return req, tests, err
+			}
+			if op.Op != "test" {
+				setPatchField(&req, field, value)
+				continue
+			}
+			// The document seen by the test is the patched value if there
+			// is one, else the stored value, possibly already tested
+			expected := patchField(req, field)
+			if expected == nil {
+				expected = testField(tests, field)
+			}
+			if expected == nil {
+				setTestField(&tests, field, value)
+			} else if *expected != value {
+				return req, tests, fmt.Errorf("%w: patch test operation %d failed", service.ErrConflict, i)
+			}
+		case "remove", "move":
+			return req, tests, service.NewValidationError(field, "cannot be removed")
+		case "copy":
+			return req, tests, service.NewValidationError(field, "copy operations are not supported")
+		default:
+			return req, tests, service.NewValidationError(field, fmt.Sprintf("unknown operation %q", op.Op))
+		}
+	}
+	return req, tests, nil
+}
+
+// decodePatchValue decodes a patch value for a known field
+func decodePatchValue(field string, raw json.RawMessage) (string, error) {
+	if _, ok := patchableFields["/"+field]; !ok {
+		return "", service.NewValidationError(field, "is not a patchable field")
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", service.NewValidationError(field, "must be a string")
+	}
+	return value, nil
+}
+
+func setPatchField(req *models.PatchUserRequest, field, value string) {
+	switch field {
+	case "name":
+		req.Name = &value
+	case "dob":
+		req.DOB = &value
+	}
+}
+
+func patchField(req models.PatchUserRequest, field string) *string {
+	switch field {
+	case "name":
+		return req.Name
+	case "dob":
+		return req.DOB
+	}
+	return nil
+}
+
+func testField(patch models.UserPatch, field string) *string {
+	switch field {
+	case "name":
+		return patch.TestName
+	case "dob":
+		return patch.TestDOB
+	}
+	return nil
+}
+
+func setTestField(patch *models.UserPatch, field, value string) {
+	switch field {
+	case "name":
+		patch.TestName = &value
+	case "dob":
+		patch.TestDOB = &value
+	}
+}
+
+// parsePatchBody dispatches on Content-Type and returns the validated patch
+func (h *UserHandler) parsePatchBody(c *fiber.Ctx) (models.UserPatch, error) {
+	var (
+		req   models.PatchUserRequest
+		patch models.UserPatch
+		err   error
+	)
+
+	switch contentType := string(c.Request().Header.ContentType()); {
+	case hasMediaType(contentType, MIMEApplicationJSONPatchJSON):
+		req, patch, err = parseJSONPatch(c.Body())
+	case hasMediaType(contentType, MIMEApplicationMergePatchJSON),
+		hasMediaType(contentType, fiber.MIMEApplicationJSON):
+		req, err = parseMergePatch(c.Body())
+	default:
+		c.Set("Accept-Patch", acceptPatch)
+		return patch, fiber.ErrUnsupportedMediaType
+	}
+	if err != nil {
+		return patch, err
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		return patch, err
+	}
+
+	patch.Name = req.Name
+	patch.DOB = req.DOB
+	return patch, nil
+}
+
+// hasMediaType reports whether a Content-Type header value has the given media type
+func hasMediaType(contentType, mediaType string) bool {
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return strings.EqualFold(strings.TrimSpace(contentType), mediaType)
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/rohanparmar/go-user-api/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMergePatch(t *testing.T) {
+	req, err := parseMergePatch([]byte(`{"name":"Alice"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", *req.Name)
+	assert.Nil(t, req.DOB)
+
+	_, err = parseMergePatch([]byte(`{"dob":null}`))
+	assert.ErrorIs(t, err, service.ErrValidation)
+
+	_, err = parseMergePatch([]byte(`{"email":"a@b.c"}`))
+	assert.ErrorIs(t, err, service.ErrValidation)
+}
+
+func TestParseJSONPatch(t *testing.T) {
+	req, tests, err := parseJSONPatch([]byte(`[
+		{"op":"test","path":"/name","value":"Alice"},
+		{"op":"replace","path":"/dob","value":"1990-01-01"}
+	]`))
+	assert.NoError(t, err)
+	assert.Nil(t, req.Name)
+	assert.Equal(t, "1990-01-01", *req.DOB)
+	assert.Equal(t, "Alice", *tests.TestName)
+
+	_, _, err = parseJSONPatch([]byte(`[{"op":"remove","path":"/name"}]`))
+	assert.ErrorIs(t, err, service.ErrValidation)
+
+	_, _, err = parseJSONPatch([]byte(`[{"op":"replace","path":"/id","value":1}]`))
+	assert.ErrorIs(t, err, service.ErrValidation)
+}
+
+func TestParseJSONPatchTestsInOrder(t *testing.T) {
+	// A test after a write sees the written value, not the stored one
+	req, tests, err := parseJSONPatch([]byte(`[
+		{"op":"test","path":"/name","value":"Alice"},
+		{"op":"replace","path":"/name","value":"Bob"},
+		{"op":"test","path":"/name","value":"Bob"}
+	]`))
+	assert.NoError(t, err)
+	assert.Equal(t, "Bob", *req.Name)
+	assert.Equal(t, "Alice", *tests.TestName)
+
+	_, _, err = parseJSONPatch([]byte(`[
+		{"op":"replace","path":"/name","value":"Bob"},
+		{"op":"test","path":"/name","value":"Alice"}
+	]`))
+	assert.ErrorIs(t, err, service.ErrConflict)
+
+	// Two tests of the same stored value cannot both pass
+	_, _, err = parseJSONPatch([]byte(`[
+		{"op":"test","path":"/dob","value":"1990-01-01"},
+		{"op":"test","path":"/dob","value":"1991-01-01"}
+	]`))
+	assert.ErrorIs(t, err, service.ErrConflict)
+}
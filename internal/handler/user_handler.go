@@ -6,14 +6,17 @@ Responsibilities:
 - Validating input using `go-playground/validator`.
 - Calling the Service layer for business logic.
 - Formatting and sending JSON responses with appropriate HTTP status codes.
+Errors are returned to Fiber and rendered by ErrorHandler (see errors.go).
 */
 package handler
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/go-playground/validator/v10"
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
 	"github.com/rohanparmar/go-user-api/internal/logger"
 	"github.com/rohanparmar/go-user-api/internal/models"
 	"github.com/rohanparmar/go-user-api/internal/service"
@@ -22,15 +25,31 @@ import (
 
 
 type UserHandler struct {
-	service  service.UserService
-	validate *validator.Validate
+	service     service.UserService
+	validate    *validator.Validate
+	importLimit int64
 }
 
-func NewUserHandler(service service.UserService) *UserHandler {
-	return &UserHandler{
-		service:  service,
-		validate: validator.New(),
+// Option configures optional UserHandler settings
+type Option func(*UserHandler)
+
+// WithImportLimit sets the largest file, in bytes, accepted by POST /users/import
+func WithImportLimit(limit int64) Option {
+	return func(h *UserHandler) {
+		h.importLimit = limit
+	}
+}
+
+func NewUserHandler(userService service.UserService, opts ...Option) *UserHandler {
+	h := &UserHandler{
+		service:     userService,
+		validate:    newValidator(),
+		importLimit: DefaultImportLimit,
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
 func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
@@ -38,31 +57,26 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 
 	// Parse request body
 	if err := c.BodyParser(&req); err != nil {
-		logger.Log.Error("Failed to parse request body", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		logger.FromContext(c.UserContext()).Error("Failed to parse request body", zap.Error(err))
+		return errInvalidBody
 	}
 
 	// Validate input
 	if err := h.validate.Struct(req); err != nil {
-		logger.Log.Error("Validation failed", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Validation failed",
-			"details": err.Error(),
-		})
+		logger.FromContext(c.UserContext()).Error("Validation failed", zap.Error(err))
+		return err
 	}
 
 	// Create user
-	user, err := h.service.CreateUser(c.Context(), req.Name, req.DOB)
+	user, err := h.service.CreateUser(c.UserContext(), req.Name, req.DOB)
 	if err != nil {
-		logger.Log.Error("Failed to create user", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		logger.FromContext(c.UserContext()).Error("Failed to create user", zap.Error(err))
+		return err
 	}
 
-	logger.Log.Info("User created successfully", zap.Int32("user_id", user.ID))
+	logger.FromContext(c.UserContext()).Info("User created successfully", zap.Int32("user_id", user.ID))
+
+	c.Set(fiber.HeaderETag, userETag(user))
 
 	// Return response without age (as per task requirement)
 	response := models.UserResponse{
@@ -79,25 +93,38 @@ func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 	idStr := c.Params("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		logger.Log.Error("Invalid user ID", zap.String("id", idStr))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid user ID",
-		})
+		logger.FromContext(c.UserContext()).Error("Invalid user ID", zap.String("id", idStr))
+		return errInvalidUserID
 	}
 
-	// Get user from service
-	user, err := h.service.GetUserByID(c.Context(), int32(id))
+	// Get user from service, optionally as it was at a point in time
+	var user db.User
+	if asOf := c.Query("as_of"); asOf != "" {
+		at, parseErr := time.Parse(time.RFC3339, asOf)
+		if parseErr != nil {
+			logger.FromContext(c.UserContext()).Error("Invalid as_of timestamp", zap.String("as_of", asOf))
+			return errInvalidAsOf
+		}
+		user, err = h.service.GetUserAsOf(c.UserContext(), int32(id), at)
+	} else {
+		user, err = h.service.GetUserByID(c.UserContext(), int32(id))
+	}
 	if err != nil {
-		logger.Log.Error("User not found", zap.Int("id", id), zap.Error(err))
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "User not found",
-		})
+		logger.FromContext(c.UserContext()).Error("Failed to get user", zap.Int("id", id), zap.Error(err))
+		return err
+	}
+
+	// Honour conditional GET
+	etag := userETag(user)
+	c.Set(fiber.HeaderETag, etag)
+	if ifNoneMatch(c, etag) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
 	// Calculate age
 	age := h.service.CalculateAge(user.Dob.Time)
 
-	logger.Log.Info("User retrieved successfully", zap.Int32("user_id", user.ID))
+	logger.FromContext(c.UserContext()).Info("User retrieved successfully", zap.Int32("user_id", user.ID))
 
 	// Return response with age
 	response := models.UserResponse{
@@ -111,23 +138,79 @@ func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 }
 
 func (h *UserHandler) ListUsers(c *fiber.Ctx) error {
+	query, err := parseListQuery(c)
+	if err != nil {
+		return err
+	}
+
+	// Presence of ?cursor= (even empty) selects keyset pagination
+	if c.Context().QueryArgs().Has("cursor") {
+		cursor := c.Query("cursor")
+		query.Cursor = &cursor
+	}
+
+	// Get paginated users
+	response, err := h.service.ListUsers(c.UserContext(), query)
+	if err != nil {
+		logger.FromContext(c.UserContext()).Error("Failed to list users", zap.Error(err))
+		return err
+	}
+
+	logger.FromContext(c.UserContext()).Info("Users listed successfully", 
+		zap.Int("page", query.Page),
+		zap.Int("limit", query.Limit),
+		zap.Int("count", len(response.Data)),
+		zap.Bool("cursor", query.Cursor != nil),
+	)
+
+	return c.JSON(response)
+}
+
+// parseListQuery reads the pagination, filter and sort parameters shared by
+// ListUsers and ExportUsers
+func parseListQuery(c *fiber.Ctx) (models.ListUsersQuery, error) {
 	// Parse page and limit from query params
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "10"))
 
-	// Get paginated users
-	response, err := h.service.ListUsers(c.Context(), page, limit)
+	query := models.ListUsersQuery{
+		Page:           page,
+		Limit:          limit,
+		IncludeDeleted: c.QueryBool("include_deleted", false),
+		IncludeTotal:   c.QueryBool("include_total", false),
+		NameContains:   c.Query("name_contains"),
+		NamePrefix:     c.Query("name_prefix"),
+		DOBFrom:        c.Query("dob_from"),
+		DOBTo:          c.Query("dob_to"),
+		CreatedAfter:   c.Query("created_after"),
+		Sort:           c.Query("sort"),
+	}
+
+	// Parse optional age bounds
+	var err error
+	if query.MinAge, err = queryInt(c, "min_age"); err != nil {
+		return query, err
+	}
+	if query.MaxAge, err = queryInt(c, "max_age"); err != nil {
+		return query, err
+	}
+	return query, nil
+}
+
+func (h *UserHandler) SearchUsers(c *fiber.Ctx) error {
+	query := c.Query("q")
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+
+	// Search users by approximate name
+	response, err := h.service.SearchUsers(c.UserContext(), query, limit)
 	if err != nil {
-		logger.Log.Error("Failed to list users", zap.Error(err))
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve users",
-		})
+		logger.FromContext(c.UserContext()).Error("Failed to search users", zap.String("q", query), zap.Error(err))
+		return err
 	}
 
-	logger.Log.Info("Users listed successfully", 
-		zap.Int("page", page),
-		zap.Int("limit", limit),
-		zap.Int64("total", response.Total),
+	logger.FromContext(c.UserContext()).Info("Users searched successfully",
+		zap.String("q", query),
+		zap.Int("count", len(response.Data)),
 	)
 
 	return c.JSON(response)
@@ -138,41 +221,34 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 	idStr := c.Params("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		logger.Log.Error("Invalid user ID", zap.String("id", idStr))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid user ID",
-		})
+		logger.FromContext(c.UserContext()).Error("Invalid user ID", zap.String("id", idStr))
+		return errInvalidUserID
 	}
 
 	var req models.UpdateUserRequest
 
 	// Parse request body
 	if err := c.BodyParser(&req); err != nil {
-		logger.Log.Error("Failed to parse request body", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		logger.FromContext(c.UserContext()).Error("Failed to parse request body", zap.Error(err))
+		return errInvalidBody
 	}
 
 	// Validate input
 	if err := h.validate.Struct(req); err != nil {
-		logger.Log.Error("Validation failed", zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Validation failed",
-			"details": err.Error(),
-		})
+		logger.FromContext(c.UserContext()).Error("Validation failed", zap.Error(err))
+		return err
 	}
 
 	// Update user
-	user, err := h.service.UpdateUser(c.Context(), int32(id), req.Name, req.DOB)
+	user, err := h.service.UpdateUser(c.UserContext(), int32(id), req.Name, req.DOB, parseIfMatch(c))
 	if err != nil {
-		logger.Log.Error("Failed to update user", zap.Int("id", id), zap.Error(err))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		logger.FromContext(c.UserContext()).Error("Failed to update user", zap.Int("id", id), zap.Error(err))
+		return err
 	}
 
-	logger.Log.Info("User updated successfully", zap.Int32("user_id", user.ID))
+	logger.FromContext(c.UserContext()).Info("User updated successfully", zap.Int32("user_id", user.ID))
+
+	c.Set(fiber.HeaderETag, userETag(user))
 
 	// Return response without age (as per task requirement)
 	response := models.UserResponse{
@@ -184,27 +260,118 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 	return c.JSON(response)
 }
 
+func (h *UserHandler) PatchUser(c *fiber.Ctx) error {
+	// Get ID from params
+	idStr := c.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		logger.FromContext(c.UserContext()).Error("Invalid user ID", zap.String("id", idStr))
+		return errInvalidUserID
+	}
+
+	// Parse merge patch or JSON patch body
+	patch, err := h.parsePatchBody(c)
+	if err != nil {
+		logger.FromContext(c.UserContext()).Error("Invalid patch document", zap.Int("id", id), zap.Error(err))
+		return err
+	}
+
+	// Patch user
+	patch.IfMatch = parseIfMatch(c)
+	user, err := h.service.PatchUser(c.UserContext(), int32(id), patch)
+	if err != nil {
+		logger.FromContext(c.UserContext()).Error("Failed to patch user", zap.Int("id", id), zap.Error(err))
+		return err
+	}
+
+	logger.FromContext(c.UserContext()).Info("User patched successfully", zap.Int32("user_id", user.ID))
+
+	c.Set(fiber.HeaderETag, userETag(user))
+
+	// Return response without age (same as PUT)
+	response := models.UserResponse{
+		ID:   user.ID,
+		Name: user.Name,
+		DOB:  user.Dob.Time.Format("2006-01-02"),
+	}
+
+	return c.JSON(response)
+}
+
 func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 	// Get ID from params
 	idStr := c.Params("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		logger.Log.Error("Invalid user ID", zap.String("id", idStr))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid user ID",
-		})
+		logger.FromContext(c.UserContext()).Error("Invalid user ID", zap.String("id", idStr))
+		return errInvalidUserID
 	}
 
 	// Delete user
-	if err := h.service.DeleteUser(c.Context(), int32(id)); err != nil {
-		logger.Log.Error("Failed to delete user", zap.Int("id", id), zap.Error(err))
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "User not found",
-		})
+	if err := h.service.DeleteUser(c.UserContext(), int32(id), parseIfMatch(c)); err != nil {
+		logger.FromContext(c.UserContext()).Error("Failed to delete user", zap.Int("id", id), zap.Error(err))
+		return err
 	}
 
-	logger.Log.Info("User deleted successfully", zap.Int("id", id))
+	logger.FromContext(c.UserContext()).Info("User deleted successfully", zap.Int("id", id))
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
+
+func (h *UserHandler) RestoreUser(c *fiber.Ctx) error {
+	// Get ID from params
+	idStr := c.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		logger.FromContext(c.UserContext()).Error("Invalid user ID", zap.String("id", idStr))
+		return errInvalidUserID
+	}
+
+	// Restore soft-deleted user
+	user, err := h.service.RestoreUser(c.UserContext(), int32(id))
+	if err != nil {
+		logger.FromContext(c.UserContext()).Error("Failed to restore user", zap.Int("id", id), zap.Error(err))
+		return err
+	}
+
+	logger.FromContext(c.UserContext()).Info("User restored successfully", zap.Int32("user_id", user.ID))
+
+	c.Set(fiber.HeaderETag, userETag(user))
+
+	response := models.UserResponse{
+		ID:   user.ID,
+		Name: user.Name,
+		DOB:  user.Dob.Time.Format("2006-01-02"),
+	}
+
+	return c.JSON(response)
+}
+
+func (h *UserHandler) GetUserHistory(c *fiber.Ctx) error {
+	// Get ID from params
+	idStr := c.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		logger.FromContext(c.UserContext()).Error("Invalid user ID", zap.String("id", idStr))
+		return errInvalidUserID
+	}
+
+	// Parse page and limit from query params
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+
+	// Get paginated audit trail
+	response, err := h.service.GetUserHistory(c.UserContext(), int32(id), page, limit)
+	if err != nil {
+		logger.FromContext(c.UserContext()).Error("Failed to get user history", zap.Int("id", id), zap.Error(err))
+		return err
+	}
+
+	logger.FromContext(c.UserContext()).Info("User history retrieved successfully",
+		zap.Int("user_id", id),
+		zap.Int64("total", response.Total),
+	)
+
+	return c.JSON(response)
+}
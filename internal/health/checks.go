@@ -0,0 +1,132 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/rohanparmar/go-user-api/internal/migrate"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"go.uber.org/zap"
+)
+
+// CheckTimeout bounds each readiness check
+const CheckTimeout = 2 * time.Second
+
+// Check is a named readiness check. Run returns details to log when the check
+// fails, and an error when the instance cannot serve traffic.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) (any, error)
+}
+
+// RunChecks runs checks concurrently and reports whether all of them passed
+func RunChecks(ctx context.Context, checks []Check) (map[string]models.CheckResult, bool) {
+	results := make(map[string]models.CheckResult, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := runCheck(ctx, check)
+
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	healthy := true
+	for _, result := range results {
+		if result.Status != models.HealthStatusOK {
+			healthy = false
+		}
+	}
+	return results, healthy
+}
+
+func runCheck(ctx context.Context, check Check) models.CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := check.Run(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Readiness check failed",
+			zap.String("check", check.Name),
+			zap.Duration("latency", time.Since(start)),
+			zap.Any("details", details),
+			zap.Error(err),
+		)
+		return models.CheckResult{Status: models.HealthStatusFailing}
+	}
+	return models.CheckResult{Status: models.HealthStatusOK}
+}
+
+type poolStats struct {
+	MaxConns             int32 `json:"max_conns"`
+	TotalConns           int32 `json:"total_conns"`
+	IdleConns            int32 `json:"idle_conns"`
+	AcquiredConns        int32 `json:"acquired_conns"`
+	ConstructingConns    int32 `json:"constructing_conns"`
+	AcquireCount         int64 `json:"acquire_count"`
+	EmptyAcquireCount    int64 `json:"empty_acquire_count"`
+	CanceledAcquireCount int64 `json:"canceled_acquire_count"`
+}
+
+// DatabaseCheck pings the database and reports the pool statistics
+func DatabaseCheck(pool *pgxpool.Pool) Check {
+	return Check{
+		Name: "database",
+		Run: func(ctx context.Context) (any, error) {
+			stat := pool.Stat()
+			stats := poolStats{
+				MaxConns:             stat.MaxConns(),
+				TotalConns:           stat.TotalConns(),
+				IdleConns:            stat.IdleConns(),
+				AcquiredConns:        stat.AcquiredConns(),
+				ConstructingConns:    stat.ConstructingConns(),
+				AcquireCount:         stat.AcquireCount(),
+				EmptyAcquireCount:    stat.EmptyAcquireCount(),
+				CanceledAcquireCount: stat.CanceledAcquireCount(),
+			}
+			return stats, pool.Ping(ctx)
+		},
+	}
+}
+
+type migrationStatus struct {
+	Version  int64 `json:"version"`
+	Expected int64 `json:"expected"`
+	Dirty    bool  `json:"dirty"`
+}
+
+// MigrationCheck fails unless the newest migration embedded in the binary
+// has been applied cleanly. A newer schema is accepted, so that an old
+// instance keeps serving during a rolling deploy.
+func MigrationCheck(migrator *migrate.Migrator) Check {
+	return Check{
+		Name: "migrations",
+		Run: func(ctx context.Context) (any, error) {
+			version, dirty, err := migrator.Version(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			status := migrationStatus{Version: version, Expected: migrator.Latest(), Dirty: dirty}
+			switch {
+			case dirty:
+				return status, fmt.Errorf("migration %d failed and the database is dirty", version)
+			case version < status.Expected:
+				return status, fmt.Errorf("database is at version %d, expected %d", version, status.Expected)
+			}
+			return status, nil
+		},
+	}
+}
@@ -0,0 +1,24 @@
+/*
+Package health tracks whether this instance should receive traffic.
+The checks in checks.go back the /readyz endpoint, and Readiness is flipped
+to failing as soon as shutdown starts, before the server stops accepting
+connections, so load balancers have time to route elsewhere.
+*/
+package health
+
+import "sync/atomic"
+
+// Readiness is safe for concurrent use. The zero value is ready.
+type Readiness struct {
+	draining atomic.Bool
+}
+
+// Drain marks the instance as shutting down; it never becomes ready again
+func (r *Readiness) Drain() {
+	r.draining.Store(true)
+}
+
+// Draining reports whether Drain has been called
+func (r *Readiness) Draining() bool {
+	return r.draining.Load()
+}
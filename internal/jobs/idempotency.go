@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/rohanparmar/go-user-api/internal/repository"
+	"go.uber.org/zap"
+)
+
+// PurgeIdempotencyKeys deletes expired Idempotency-Key records every interval
+// until ctx is cancelled. Expired keys are already ignored when claimed, so
+// this only keeps the table small.
+func PurgeIdempotencyKeys(ctx context.Context, store repository.IdempotencyRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.FromContext(ctx).Info("Idempotency key purge job started", zap.Duration("interval", interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.FromContext(ctx).Info("Idempotency key purge job stopped")
+			return
+		case <-ticker.C:
+			purged, err := store.PurgeExpired(ctx, time.Now())
+			if err != nil {
+				logger.FromContext(ctx).Error("Failed to purge idempotency keys", zap.Error(err))
+				continue
+			}
+			if purged > 0 {
+				logger.FromContext(ctx).Info("Purged idempotency keys", zap.Int64("count", purged))
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+/*
+Package jobs contains background tasks that run alongside the HTTP server.
+PurgeDeletedUsers periodically hard-deletes users whose soft delete is older
+than the configured retention window, and PurgeIdempotencyKeys drops
+expired Idempotency-Key records.
+*/
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/rohanparmar/go-user-api/internal/auth"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/rohanparmar/go-user-api/internal/service"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+	"go.uber.org/zap"
+)
+
+// PurgeDeletedUsers runs the purge every interval until ctx is cancelled.
+// It is meant to be started in its own goroutine.
+func PurgeDeletedUsers(ctx context.Context, userService service.UserService, interval, retention time.Duration) {
+	// The job acts on its own behalf, which the access policy allows, and
+	// purges every tenant
+	ctx = auth.WithPrincipal(ctx, auth.SystemPrincipal)
+	ctx = tenant.WithID(ctx, tenant.All)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.FromContext(ctx).Info("Purge job started",
+		zap.Duration("interval", interval),
+		zap.Duration("retention", retention),
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.FromContext(ctx).Info("Purge job stopped")
+			return
+		case <-ticker.C:
+			purged, err := userService.PurgeDeletedUsers(ctx, retention)
+			if err != nil {
+				logger.FromContext(ctx).Error("Failed to purge deleted users", zap.Error(err))
+				continue
+			}
+			if purged > 0 {
+				logger.FromContext(ctx).Info("Purged deleted users", zap.Int64("count", purged))
+			}
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type contextKey struct{}
+
+// WithContext returns a copy of ctx carrying l, typically a request-scoped
+// logger created by the RequestID middleware
+func WithContext(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx, or the global Log, with the
+// trace_id and span_id of the current span added (see TraceFields).
+// Before InitLogger has run it returns a no-op logger.
+func FromContext(ctx context.Context) *zap.Logger {
+	l, ok := ctx.Value(contextKey{}).(*zap.Logger)
+	if !ok {
+		l = Log
+	}
+	if l == nil {
+		return zap.NewNop()
+	}
+	if fields := TraceFields(ctx); fields != nil {
+		return l.With(fields...)
+	}
+	return l
+}
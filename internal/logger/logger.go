@@ -11,21 +11,22 @@ import (
 
 var Log *zap.Logger
 
-// InitLogger initializes the Uber Zap logger
-func InitLogger(env string) error {
-	var err error
-	
-	if env == "production" {
-		Log, err = zap.NewProduction()
-	} else {
-		Log, err = zap.NewDevelopment()
-	}
-	
+// InitLogger initializes the Uber Zap logger at the given level
+// ("debug", "info", "warn" or "error")
+func InitLogger(env string, level string) error {
+	lvl, err := zap.ParseAtomicLevel(level)
 	if err != nil {
 		return err
 	}
-	
-	return nil
+
+	cfg := zap.NewDevelopmentConfig()
+	if env == "production" {
+		cfg = zap.NewProductionConfig()
+	}
+	cfg.Level = lvl
+
+	Log, err = cfg.Build()
+	return err
 }
 
 // Sync flushes any buffered log entries
@@ -0,0 +1,27 @@
+/*
+Package middleware provides HTTP middleware functions.
+AuditContext middleware stores the request ID and the acting principal in the
+request's user context so the repository can record them in the audit trail.
+Must be used after RequestID and Authenticate middleware.
+*/
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/audit"
+	"github.com/rohanparmar/go-user-api/internal/auth"
+)
+
+// AuditContext middleware adds audit metadata to the user context
+func AuditContext() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := audit.WithRequestID(c.UserContext(), GetRequestID(c))
+
+		if principal, ok := auth.FromContext(ctx); ok {
+			ctx = audit.WithActor(ctx, principal.Subject)
+		}
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Package middleware provides HTTP middleware functions.
+Authenticate middleware resolves the caller from its API key or bearer token
+and stores the principal in the user context; RequireScope then guards each
+route. Requests without credentials pass Authenticate as anonymous, so public
+endpoints such as the probes stay open, and are rejected by RequireScope.
+*/
+package middleware
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/auth"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/rohanparmar/go-user-api/internal/service"
+	"go.uber.org/zap"
+)
+
+// APIKeyHeader carries the caller's API key
+const APIKeyHeader = "X-API-Key"
+
+// WWW-Authenticate challenges sent with 401 responses (RFC 6750 for Bearer)
+const (
+	apiKeyChallenge       = `ApiKey header="` + APIKeyHeader + `"`
+	bearerChallenge       = `Bearer realm="go-user-api"`
+	invalidTokenChallenge = `Bearer realm="go-user-api", error="invalid_token"`
+)
+
+// authChallengeKey is the Fiber locals key holding the challenges Authenticate
+// accepts, for RequireScope to send
+const authChallengeKey = "authChallenge"
+
+var (
+	errInvalidAPIKey          = fiber.NewError(fiber.StatusUnauthorized, "Invalid API key")
+	errInvalidToken           = fiber.NewError(fiber.StatusUnauthorized, "Invalid bearer token")
+	errAuthenticationRequired = fiber.NewError(fiber.StatusUnauthorized, "Authentication required")
+)
+
+// Authenticate middleware checks the API key or bearer token, if any, and
+// stores the principal. tokens may be nil, in which case bearer tokens are
+// rejected.
+func Authenticate(keys service.APIKeyService, tokens *auth.JWTVerifier) fiber.Handler {
+	challenge := apiKeyChallenge
+	if tokens != nil {
+		challenge += ", " + bearerChallenge
+	}
+
+	return func(c *fiber.Ctx) error {
+		c.Locals(authChallengeKey, challenge)
+		ctx := c.UserContext()
+
+		var principal auth.Principal
+		if key := c.Get(APIKeyHeader); key != "" {
+			p, err := keys.Authenticate(ctx, key)
+			if errors.Is(err, service.ErrInvalidAPIKey) {
+				c.Set(fiber.HeaderWWWAuthenticate, challenge)
+				return errInvalidAPIKey
+			}
+			if err != nil {
+				return err
+			}
+			principal = p
+		} else if token, ok := bearerToken(c); ok {
+			if tokens == nil {
+				c.Set(fiber.HeaderWWWAuthenticate, challenge)
+				return errInvalidToken
+			}
+			p, err := tokens.Verify(ctx, token)
+			if err != nil {
+				logger.FromContext(ctx).Debug("Rejected bearer token", zap.Error(err))
+				c.Set(fiber.HeaderWWWAuthenticate, invalidTokenChallenge)
+				return errInvalidToken
+			}
+			principal = p
+		} else {
+			return c.Next()
+		}
+
+		c.SetUserContext(auth.WithPrincipal(ctx, principal))
+		return c.Next()
+	}
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header
+func bearerToken(c *fiber.Ctx) (string, bool) {
+	scheme, token, ok := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// RequireScope middleware rejects callers that were not granted scope:
+// 401 when no credentials were sent, 403 when the scope is missing
+func RequireScope(scope auth.Scope) fiber.Handler {
+	forbidden := fiber.NewError(fiber.StatusForbidden, "Missing required scope "+string(scope))
+
+	return func(c *fiber.Ctx) error {
+		principal, ok := auth.FromContext(c.UserContext())
+		if !ok {
+			challenge, _ := c.Locals(authChallengeKey).(string)
+			if challenge == "" {
+				challenge = apiKeyChallenge
+			}
+			c.Set(fiber.HeaderWWWAuthenticate, challenge)
+			return errAuthenticationRequired
+		}
+		if !principal.HasScope(scope) {
+			return forbidden
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/audit"
+	"github.com/rohanparmar/go-user-api/internal/auth"
+	"github.com/rohanparmar/go-user-api/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAPIKeys accepts the keys in its map
+type fakeAPIKeys struct {
+	service.APIKeyService
+	principals map[string]auth.Principal
+}
+
+func (f *fakeAPIKeys) Authenticate(ctx context.Context, key string) (auth.Principal, error) {
+	if principal, ok := f.principals[key]; ok {
+		return principal, nil
+	}
+	return auth.Principal{}, service.ErrInvalidAPIKey
+}
+
+func TestAuthenticateAndRequireScope(t *testing.T) {
+	keys := &fakeAPIKeys{principals: map[string]auth.Principal{
+		"reader": {Subject: "apikey:reader", Scopes: []auth.Scope{auth.ScopeUsersRead}},
+		"admin":  {Subject: "apikey:admin", Scopes: []auth.Scope{auth.ScopeUsersAdmin}},
+	}}
+
+	app := fiber.New()
+	app.Use(Authenticate(keys, nil), AuditContext())
+	app.Get("/healthz", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Delete("/users/1", RequireScope(auth.ScopeUsersWrite), func(c *fiber.Ctx) error {
+		return c.SendString(audit.Actor(c.UserContext()))
+	})
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		key       string
+		bearer    string
+		status    int
+		challenge bool
+	}{
+		{name: "Public route", method: fiber.MethodGet, path: "/healthz", status: fiber.StatusOK},
+		{name: "No key", method: fiber.MethodDelete, path: "/users/1", status: fiber.StatusUnauthorized, challenge: true},
+		{name: "Invalid key", method: fiber.MethodGet, path: "/healthz", key: "bogus", status: fiber.StatusUnauthorized, challenge: true},
+		{name: "Missing scope", method: fiber.MethodDelete, path: "/users/1", key: "reader", status: fiber.StatusForbidden},
+		{name: "Bearer token without JWKS", method: fiber.MethodDelete, path: "/users/1", bearer: "eyJ.e30.sig", status: fiber.StatusUnauthorized, challenge: true},
+		{name: "Stronger scope", method: fiber.MethodDelete, path: "/users/1", key: "admin", status: fiber.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			if tt.bearer != "" {
+				req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tt.bearer)
+			}
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, tt.challenge, resp.Header.Get(fiber.HeaderWWWAuthenticate) != "")
+		})
+	}
+}
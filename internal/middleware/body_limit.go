@@ -0,0 +1,53 @@
+/*
+Package middleware provides HTTP middleware functions.
+BodyLimit middleware enforces a maximum request body size. The server streams
+request bodies so POST /users/import can read files of any size, which also
+lifts fasthttp's own limit; every other route buffers its body here instead,
+up to the limit. fasthttp does not drain a streamed body left unread, so the
+connection is closed whenever that may happen.
+Must be used before any middleware that can reject a request.
+*/
+package middleware
+
+import (
+	"io"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var errBodyUnreadable = fiber.NewError(fiber.StatusBadRequest, "Request body could not be read")
+
+// BodyLimit middleware rejects bodies larger than limit bytes with 413 and
+// buffers the others, so c.Body() never reads more than limit. Requests to
+// the streamed paths are passed on untouched; they must cap their own body.
+func BodyLimit(limit int, streamed ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if slices.Contains(streamed, c.Path()) {
+			c.Context().SetConnectionClose()
+			return c.Next()
+		}
+		if c.Request().Header.ContentLength() > limit {
+			c.Context().SetConnectionClose()
+			return fiber.ErrRequestEntityTooLarge
+		}
+
+		stream := c.Context().RequestBodyStream()
+		if stream == nil {
+			return c.Next()
+		}
+
+		// Chunked bodies have no length, so read one byte past the limit to tell
+		body, err := io.ReadAll(io.LimitReader(stream, int64(limit)+1))
+		if err != nil {
+			c.Context().SetConnectionClose()
+			return errBodyUnreadable
+		}
+		if len(body) > limit {
+			c.Context().SetConnectionClose()
+			return fiber.ErrRequestEntityTooLarge
+		}
+		c.Request().SetBody(body)
+		return c.Next()
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLimit(t *testing.T) {
+	app := fiber.New(fiber.Config{StreamRequestBody: true})
+	app.Use(BodyLimit(16, "/stream"))
+	app.Post("/buffered", func(c *fiber.Ctx) error {
+		return c.SendString(strconv.Itoa(len(c.Body())))
+	})
+	app.Post("/stream", func(c *fiber.Ctx) error {
+		n, err := io.Copy(io.Discard, c.Context().RequestBodyStream())
+		if err != nil {
+			return err
+		}
+		return c.SendString(strconv.FormatInt(n, 10))
+	})
+
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		chunked bool
+		status  int
+		length  string
+	}{
+		{name: "Within limit", path: "/buffered", body: "0123456789", status: fiber.StatusOK, length: "10"},
+		{name: "Chunked within limit", path: "/buffered", body: "0123456789", chunked: true, status: fiber.StatusOK, length: "10"},
+		{name: "Over limit", path: "/buffered", body: strings.Repeat("x", 17), status: fiber.StatusRequestEntityTooLarge},
+		{name: "Chunked over limit", path: "/buffered", body: strings.Repeat("x", 17), chunked: true, status: fiber.StatusRequestEntityTooLarge},
+		{name: "Streamed path", path: "/stream", body: strings.Repeat("x", 100), status: fiber.StatusOK, length: "100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPost, tt.path, strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = 0
+				req.TransferEncoding = []string{"chunked"}
+			}
+			resp, err := app.Test(req)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.status, resp.StatusCode)
+			if tt.status == fiber.StatusOK {
+				got, _ := io.ReadAll(resp.Body)
+				assert.Equal(t, tt.length, string(got))
+			}
+		})
+	}
+}
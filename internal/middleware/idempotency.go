@@ -0,0 +1,140 @@
+/*
+Package middleware provides HTTP middleware functions.
+Idempotency middleware lets clients retry non-idempotent requests safely.
+The first request carrying an Idempotency-Key header is processed and its
+response is stored; retries with the same key and body replay that response
+instead of running the handler again.
+Must be used after AuditContext middleware, as keys are scoped per actor.
+The tenant is part of the request fingerprint, so a key cannot replay a
+response across tenants.
+*/
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/audit"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/rohanparmar/go-user-api/internal/repository"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+	"go.uber.org/zap"
+)
+
+// Idempotency headers, following the IETF Idempotency-Key draft
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKeyLength bounds the keys we are willing to store
+const maxIdempotencyKeyLength = 255
+
+// idempotentHeaders are the response headers stored and replayed with the body
+var idempotentHeaders = []string{fiber.HeaderContentType, fiber.HeaderLocation, fiber.HeaderETag}
+
+var (
+	errIdempotencyKeyTooLong = fiber.NewError(fiber.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+	errIdempotencyKeyReused  = fiber.NewError(fiber.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+	errIdempotencyInProgress = fiber.NewError(fiber.StatusConflict, "A request with this Idempotency-Key is still being processed")
+)
+
+// Idempotency middleware stores responses by Idempotency-Key for ttl.
+// Requests without the header are passed through unchanged. Server errors
+// (5xx) are not stored, so the client can retry them with the same key.
+// Retries get 409 while the first request runs, for up to lockTimeout: after
+// that the first request is presumed lost and a retry runs in its place, so
+// lockTimeout must exceed the longest request.
+func Idempotency(store repository.IdempotencyRepository, ttl, lockTimeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyKeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return errIdempotencyKeyTooLong
+		}
+
+		ctx := c.UserContext()
+		actor := audit.Actor(ctx)
+		hash := requestHash(c)
+
+		record, claimed, err := store.Claim(ctx, actor, key, hash, time.Now().Add(ttl), lockTimeout)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			if !bytes.Equal(record.RequestHash, hash) {
+				return errIdempotencyKeyReused
+			}
+			if !record.StatusCode.Valid {
+				return errIdempotencyInProgress
+			}
+			return replayResponse(c, record.StatusCode.Int32, record.ResponseHeaders, record.ResponseBody)
+		}
+
+		// Render errors here so that the stored response is the one the client sees
+		if err := c.Next(); err != nil {
+			if err := c.App().Config().ErrorHandler(c, err); err != nil {
+				c.Status(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			if err := store.Release(ctx, actor, key); err != nil {
+				logger.FromContext(ctx).Error("Failed to release idempotency key", zap.Error(err))
+			}
+			return nil
+		}
+
+		headers, err := json.Marshal(responseHeaders(c))
+		if err == nil {
+			err = store.Complete(ctx, actor, key, int32(status), headers, c.Response().Body())
+		}
+		if err != nil {
+			logger.FromContext(ctx).Error("Failed to store idempotent response", zap.Error(err))
+		}
+		return nil
+	}
+}
+
+// requestHash fingerprints the request a key was first used with
+func requestHash(c *fiber.Ctx) []byte {
+	h := sha256.New()
+	h.Write([]byte(tenant.ID(c.UserContext())))
+	h.Write([]byte{0})
+	h.Write([]byte(c.Method()))
+	h.Write([]byte{0})
+	h.Write([]byte(c.OriginalURL()))
+	h.Write([]byte{0})
+	h.Write(c.Body())
+	return h.Sum(nil)
+}
+
+func responseHeaders(c *fiber.Ctx) map[string]string {
+	headers := make(map[string]string, len(idempotentHeaders))
+	for _, name := range idempotentHeaders {
+		if value := c.GetRespHeader(name); value != "" {
+			headers[name] = value
+		}
+	}
+	return headers
+}
+
+func replayResponse(c *fiber.Ctx, status int32, headers, body []byte) error {
+	var stored map[string]string
+	if len(headers) > 0 {
+		if err := json.Unmarshal(headers, &stored); err != nil {
+			return err
+		}
+	}
+	for name, value := range stored {
+		c.Set(name, value)
+	}
+	c.Set(IdempotencyReplayedHeader, "true")
+	return c.Status(int(status)).Send(body)
+}
@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	m.Run()
+}
+
+// memoryIdempotencyStore keeps keys in a map, ignoring expiry. Its clock
+// only moves when the test advances it.
+type memoryIdempotencyStore struct {
+	records      map[string]db.IdempotencyKey
+	now          time.Time
+	failComplete bool
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: map[string]db.IdempotencyKey{}, now: time.Now()}
+}
+
+func (s *memoryIdempotencyStore) Claim(ctx context.Context, actor, key string, requestHash []byte, expiresAt time.Time, lockTimeout time.Duration) (db.IdempotencyKey, bool, error) {
+	record, ok := s.records[actor+"/"+key]
+	stale := ok && !record.StatusCode.Valid && record.LockedUntil.Time.Before(s.now) && bytes.Equal(record.RequestHash, requestHash)
+	if ok && !stale {
+		return record, false, nil
+	}
+	record = db.IdempotencyKey{
+		Actor:       actor,
+		Key:         key,
+		RequestHash: requestHash,
+		LockedUntil: pgtype.Timestamptz{Time: s.now.Add(lockTimeout), Valid: true},
+	}
+	s.records[actor+"/"+key] = record
+	return record, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, actor, key string, status int32, headers, body []byte) error {
+	if s.failComplete {
+		return errors.New("connection lost")
+	}
+	record := s.records[actor+"/"+key]
+	record.StatusCode = pgtype.Int4{Int32: status, Valid: true}
+	record.ResponseHeaders = headers
+	record.ResponseBody = append([]byte(nil), body...)
+	s.records[actor+"/"+key] = record
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, actor, key string) error {
+	delete(s.records, actor+"/"+key)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func newIdempotentApp(store *memoryIdempotencyStore, handler fiber.Handler) *fiber.App {
+	app := fiber.New()
+	app.Use(AuditContext())
+	app.Post("/users", Idempotency(store, time.Hour, time.Minute), handler)
+	return app
+}
+
+func postWithKey(t *testing.T, app *fiber.App, key, body string) (int, string, http.Header) {
+	req := httptest.NewRequest(fiber.MethodPost, "/users", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+
+	payload, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return resp.StatusCode, string(payload), resp.Header
+}
+
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	calls := 0
+	app := newIdempotentApp(newMemoryIdempotencyStore(), func(c *fiber.Ctx) error {
+		calls++
+		c.Location("/users/1")
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"id": calls})
+	})
+
+	status, body, _ := postWithKey(t, app, "abc", `{"name":"Alice"}`)
+	assert.Equal(t, fiber.StatusCreated, status)
+	assert.JSONEq(t, `{"id":1}`, body)
+
+	status, body, header := postWithKey(t, app, "abc", `{"name":"Alice"}`)
+	assert.Equal(t, fiber.StatusCreated, status)
+	assert.JSONEq(t, `{"id":1}`, body)
+	assert.Equal(t, "true", header.Get(IdempotencyReplayedHeader))
+	assert.Equal(t, "/users/1", header.Get(fiber.HeaderLocation))
+	assert.Equal(t, fiber.MIMEApplicationJSON, header.Get(fiber.HeaderContentType))
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotencyRejectsKeyReuseWithDifferentBody(t *testing.T) {
+	app := newIdempotentApp(newMemoryIdempotencyStore(), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	status, _, _ := postWithKey(t, app, "abc", `{"name":"Alice"}`)
+	assert.Equal(t, fiber.StatusCreated, status)
+
+	status, _, _ = postWithKey(t, app, "abc", `{"name":"Bob"}`)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, status)
+}
+
+func TestIdempotencyConflictWhileInProgress(t *testing.T) {
+	var app *fiber.App
+	retryStatus := 0
+	app = newIdempotentApp(newMemoryIdempotencyStore(), func(c *fiber.Ctx) error {
+		// Retry before the first request has finished
+		retryStatus, _, _ = postWithKey(t, app, "abc", `{"name":"Alice"}`)
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	status, _, _ := postWithKey(t, app, "abc", `{"name":"Alice"}`)
+	assert.Equal(t, fiber.StatusCreated, status)
+	assert.Equal(t, fiber.StatusConflict, retryStatus)
+}
+
+func TestIdempotencyTakesOverStaleClaim(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	calls := 0
+	app := newIdempotentApp(store, func(c *fiber.Ctx) error {
+		calls++
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	// The response of the first request is lost, leaving the key in progress
+	store.failComplete = true
+	status, _, _ := postWithKey(t, app, "abc", `{"name":"Alice"}`)
+	assert.Equal(t, fiber.StatusCreated, status)
+	store.failComplete = false
+
+	status, _, _ = postWithKey(t, app, "abc", `{"name":"Alice"}`)
+	assert.Equal(t, fiber.StatusConflict, status)
+
+	// Once the lock has run out, a different request is still refused but a
+	// retry of the same one runs again
+	store.now = store.now.Add(2 * time.Minute)
+	status, _, _ = postWithKey(t, app, "abc", `{"name":"Bob"}`)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, status)
+
+	status, _, _ = postWithKey(t, app, "abc", `{"name":"Alice"}`)
+	assert.Equal(t, fiber.StatusCreated, status)
+	assert.Equal(t, 2, calls)
+
+	status, _, header := postWithKey(t, app, "abc", `{"name":"Alice"}`)
+	assert.Equal(t, fiber.StatusCreated, status)
+	assert.Equal(t, "true", header.Get(IdempotencyReplayedHeader))
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyReleasesKeyOnServerError(t *testing.T) {
+	calls := 0
+	app := newIdempotentApp(newMemoryIdempotencyStore(), func(c *fiber.Ctx) error {
+		calls++
+		if calls == 1 {
+			return fiber.ErrServiceUnavailable
+		}
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	status, _, _ := postWithKey(t, app, "abc", `{"name":"Alice"}`)
+	assert.Equal(t, fiber.StatusServiceUnavailable, status)
+
+	status, _, _ = postWithKey(t, app, "abc", `{"name":"Alice"}`)
+	assert.Equal(t, fiber.StatusCreated, status)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyWithoutKeyPassesThrough(t *testing.T) {
+	calls := 0
+	app := newIdempotentApp(newMemoryIdempotencyStore(), func(c *fiber.Ctx) error {
+		calls++
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	postWithKey(t, app, "", `{"name":"Alice"}`)
+	postWithKey(t, app, "", `{"name":"Alice"}`)
+	assert.Equal(t, 2, calls)
+
+	status, _, _ := postWithKey(t, app, strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+}
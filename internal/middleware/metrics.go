@@ -0,0 +1,75 @@
+/*
+Package middleware provides HTTP middleware functions.
+Metrics middleware records request counts, latency and in-flight requests,
+labelled by the matched route template so that /users/1 and /users/2 share
+a series.
+*/
+package middleware
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/metrics"
+)
+
+// unmatchedRoute labels requests that did not match any route
+const unmatchedRoute = "unmatched"
+
+// Metrics middleware records HTTP metrics. It renders errors itself with the
+// app's ErrorHandler to record their status, so register it after RequestID.
+func Metrics(m *metrics.Metrics) fiber.Handler {
+	var routes routeIndex
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		m.InFlight.Inc()
+		defer afterStream(c, m.InFlight.Dec)
+
+		err := c.Next()
+
+		// Render the error now so that its status is the one recorded
+		if err != nil {
+			if handlerErr := c.App().Config().ErrorHandler(c, err); handlerErr != nil {
+				c.Status(fiber.StatusInternalServerError)
+			}
+		}
+
+		route := unmatchedRoute
+		if path, ok := routes.matched(c); ok {
+			route = path
+		}
+
+		// Streamed responses are timed until they are written
+		method, status := c.Method(), strconv.Itoa(c.Response().StatusCode())
+		afterStream(c, func() {
+			m.Requests.WithLabelValues(method, route, status).Inc()
+			m.RequestDuration.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+		})
+		return nil
+	}
+}
+
+// routeIndex tells whether a request ended on a route or in a middleware.
+// When no route matched, c.Route() is the last middleware that ran, and Fiber
+// gives middleware routes the request's method, so they are only told apart
+// by being left out of app.GetRoutes(true). The index is built on first use,
+// once every route is registered.
+type routeIndex struct {
+	once   sync.Once
+	routes map[string]bool
+}
+
+// matched returns the template of the route that handled the request, and
+// false when no route matched
+func (idx *routeIndex) matched(c *fiber.Ctx) (string, bool) {
+	idx.once.Do(func() {
+		idx.routes = make(map[string]bool)
+		for _, r := range c.App().GetRoutes(true) {
+			idx.routes[r.Method+" "+r.Path] = true
+		}
+	})
+	r := c.Route()
+	return r.Path, idx.routes[r.Method+" "+r.Path]
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rohanparmar/go-user-api/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsLabelsByRouteTemplate(t *testing.T) {
+	m := metrics.New(prometheus.NewRegistry())
+	app := fiber.New()
+	app.Use(Metrics(m))
+	// Requests ending in a later middleware are still unmatched
+	app.Use(func(c *fiber.Ctx) error {
+		if c.Get(fiber.HeaderAuthorization) == "" && c.Path() == "/private" {
+			return fiber.ErrUnauthorized
+		}
+		return c.Next()
+	})
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		assert.Equal(t, float64(1), testutil.ToFloat64(m.InFlight))
+		if c.Params("id") == "0" {
+			return fiber.ErrNotFound
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/0", "/nowhere", "/private"} {
+		_, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.Requests.WithLabelValues("GET", "/users/:id", "200")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.Requests.WithLabelValues("GET", "/users/:id", "404")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.Requests.WithLabelValues("GET", unmatchedRoute, "404")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.Requests.WithLabelValues("GET", unmatchedRoute, "401")))
+	assert.Equal(t, 4, testutil.CollectAndCount(m.RequestDuration))
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.InFlight))
+}
@@ -2,6 +2,7 @@
 Package middleware provides HTTP middleware functions.
 RequestDuration middleware measures the time taken to process each request.
 It logs the duration, HTTP status, method, and path using the structured logger context.
+Errors are rendered with the app's ErrorHandler first, so their status is the one logged.
 Must be used after RequestID middleware to include the request ID in logs.
 */
 package middleware
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 	"github.com/rohanparmar/go-user-api/internal/logger"
 	"go.uber.org/zap"
 )
@@ -23,21 +25,26 @@ func RequestDuration() fiber.Handler {
 		// Process request
 		err := c.Next()
 		
-		// Calculate duration
-		duration := time.Since(start)
+		// Render the error now so that its status is the one logged
+		if err != nil {
+			if handlerErr := c.App().Config().ErrorHandler(c, err); handlerErr != nil {
+				c.Status(fiber.StatusInternalServerError)
+			}
+		}
 		
-		// Get request ID from context
-		requestID, _ := c.Locals("requestID").(string)
+		// Log request details with duration once a streamed body is written;
+		// the request logger adds the request ID
+		log := logger.FromContext(c.UserContext())
+		method, path, status := c.Method(), utils.CopyString(c.Path()), c.Response().StatusCode()
+		afterStream(c, func() {
+			log.Info("Request completed",
+				zap.String("method", method),
+				zap.String("path", path),
+				zap.Int("status", status),
+				zap.Duration("duration", time.Since(start)),
+			)
+		})
 		
-		// Log request details with duration
-		logger.Log.Info("Request completed",
-			zap.String("request_id", requestID),
-			zap.String("method", c.Method()),
-			zap.String("path", c.Path()),
-			zap.Int("status", c.Response().StatusCode()),
-			zap.Duration("duration", duration),
-		)
-		
-		return err
+		return nil
 	}
 }
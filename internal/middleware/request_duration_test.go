@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequestDurationLogsErrorStatus(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger.Log = zap.New(core)
+	defer func() { logger.Log = zap.NewNop() }()
+
+	app := fiber.New()
+	app.Use(RequestDuration())
+	app.Get("/ok", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	app.Get("/missing", func(c *fiber.Ctx) error {
+		return fiber.ErrNotFound
+	})
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{path: "/ok", status: fiber.StatusNoContent},
+		{path: "/missing", status: fiber.StatusNotFound},
+		{path: "/unrouted", status: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			logs.TakeAll()
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, tt.path, nil))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+
+			completed := logs.FilterMessage("Request completed").All()
+			if assert.Len(t, completed, 1) {
+				assert.Equal(t, int64(tt.status), completed[0].ContextMap()["status"])
+			}
+		})
+	}
+}
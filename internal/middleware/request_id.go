@@ -1,29 +1,73 @@
 /*
 Package middleware provides HTTP middleware functions.
-RequestID middleware generates a unique UUID for each incoming request.
-This ID is added to the response headers (X-Request-ID) and the context,
-allowing for request tracing across logs.
+RequestID middleware reuses the X-Request-ID set by the gateway when it is
+valid, and generates a UUID otherwise. The ID is added to the response headers
+and to a request-scoped logger stored in the user context, so every log line
+written through logger.FromContext can be traced back to the request.
 */
 package middleware
 
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"go.uber.org/zap"
 )
 
-// RequestID middleware adds a unique request ID to each request
+// RequestIDHeader carries the request ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the Fiber locals key holding the request ID
+const requestIDKey = "requestID"
+
+// maxRequestIDLength bounds incoming IDs, which end up in every log line
+const maxRequestIDLength = 128
+
+// RequestID middleware adds a request ID and a request logger to each request
 func RequestID() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Generate a new UUID for the request
-		requestID := uuid.New().String()
+		// Reuse the caller's ID so logs can be correlated across services
+		requestID := c.Get(RequestIDHeader)
+		rejected := requestID != "" && !validRequestID(requestID)
+		if requestID == "" || rejected {
+			requestID = uuid.New().String()
+		}
 		
 		// Set request ID in context (for logging)
-		c.Locals("requestID", requestID)
+		c.Locals(requestIDKey, requestID)
+		requestLogger := logger.FromContext(c.UserContext()).With(zap.String("request_id", requestID))
+		c.SetUserContext(logger.WithContext(c.UserContext(), requestLogger))
+		if rejected {
+			requestLogger.Warn("Ignoring invalid X-Request-ID header")
+		}
 		
 		// Add request ID to response header
-		c.Set("X-Request-ID", requestID)
+		c.Set(RequestIDHeader, requestID)
 		
 		// Continue to next handler
 		return c.Next()
 	}
 }
+
+// GetRequestID returns the request ID assigned by the RequestID middleware
+func GetRequestID(c *fiber.Ctx) string {
+	requestID, _ := c.Locals(requestIDKey).(string)
+	return requestID
+}
+
+// validRequestID accepts up to maxRequestIDLength letters, digits and the
+// separators commonly used by gateways, which keeps IDs safe to log and echo
+func validRequestID(id string) bool {
+	if len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':', r == '/', r == '+', r == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
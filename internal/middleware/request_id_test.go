@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequestIDReusesValidIncomingID(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger.Log = zap.New(core)
+	defer func() { logger.Log = zap.NewNop() }()
+
+	app := fiber.New()
+	app.Use(RequestID())
+	app.Get("/", func(c *fiber.Ctx) error {
+		logger.FromContext(c.UserContext()).Info("handled")
+		return c.SendString(GetRequestID(c))
+	})
+
+	tests := []struct {
+		name     string
+		incoming string
+		reused   bool
+	}{
+		{name: "Gateway ID", incoming: "gw-7f3a:1697443200.123", reused: true},
+		{name: "Missing", incoming: "", reused: false},
+		{name: "Invalid characters", incoming: "abc\"; DROP", reused: false},
+		{name: "Too long", incoming: strings.Repeat("a", maxRequestIDLength+1), reused: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.TakeAll()
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+
+			requestID := resp.Header.Get(RequestIDHeader)
+			if tt.reused {
+				assert.Equal(t, tt.incoming, requestID)
+			} else {
+				assert.NotEqual(t, tt.incoming, requestID)
+				assert.Len(t, requestID, 36)
+			}
+
+			handled := logs.FilterMessage("handled").All()
+			if assert.Len(t, handled, 1) {
+				assert.Equal(t, requestID, handled[0].ContextMap()["request_id"])
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Package middleware provides HTTP middleware functions.
+StreamBody streams a response body once the handler has returned. Tracing,
+Metrics and RequestDuration wait for the stream to be written before they
+finish, so the time spent streaming counts towards the request.
+*/
+package middleware
+
+import (
+	"bufio"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// streamKey is the c.Locals key of the bodyStream of a streamed response
+type streamKey struct{}
+
+// bodyStream holds what runs once a streamed body has been written
+type bodyStream struct {
+	mu   sync.Mutex
+	done []func()
+}
+
+// StreamBody sets write as the body stream writer of the response, like
+// fasthttp's SetBodyStreamWriter. write runs after the handler returns, in
+// another goroutine, so it must not use c.
+func StreamBody(c *fiber.Ctx, write func(w *bufio.Writer)) {
+	stream := &bodyStream{}
+	c.Locals(streamKey{}, stream)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer stream.finish()
+		write(w)
+	})
+}
+
+func (s *bodyStream) finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, fn := range s.done {
+		fn()
+	}
+	s.done = nil
+}
+
+// afterStream runs fn once the response body set by StreamBody has been
+// written, or right away if the response is not streamed. fn may run in
+// another goroutine, so it must not use c.
+func afterStream(c *fiber.Ctx, fn func()) {
+	stream, ok := c.Locals(streamKey{}).(*bodyStream)
+	if !ok || !c.Response().IsBodyStream() {
+		fn()
+		return
+	}
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	stream.done = append(stream.done, fn)
+}
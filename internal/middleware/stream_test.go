@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rohanparmar/go-user-api/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStreamBodyIsTimed(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	m := metrics.New(prometheus.NewRegistry())
+
+	app := fiber.New()
+	app.Use(Tracing(), Metrics(m))
+	app.Get("/export", func(c *fiber.Ctx) error {
+		StreamBody(c, func(w *bufio.Writer) {
+			time.Sleep(delay)
+			w.WriteString("done")
+		})
+		return nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/export", nil))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "done", string(body))
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 1) {
+		assert.GreaterOrEqual(t, spans[0].EndTime().Sub(spans[0].StartTime()), delay)
+	}
+
+	var observed dto.Metric
+	require.NoError(t, m.RequestDuration.WithLabelValues("GET", "/export", "200").(prometheus.Histogram).Write(&observed))
+	assert.Equal(t, uint64(1), observed.GetHistogram().GetSampleCount())
+	assert.GreaterOrEqual(t, observed.GetHistogram().GetSampleSum(), delay.Seconds())
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.InFlight))
+}
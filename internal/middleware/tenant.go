@@ -0,0 +1,73 @@
+/*
+Package middleware provides HTTP middleware functions.
+Tenant middleware resolves the tenant a request acts for and stores it in the
+user context, where the repository scopes every query to it. Credentials bound
+to a tenant always act for it, and credentials bound to none are rejected.
+Only credentials granted every tenant, and anonymous requests, may name a
+tenant in a header.
+Must be used after Authenticate middleware.
+*/
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/auth"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+	"go.uber.org/zap"
+)
+
+// TenantHeader is the default header naming the tenant of a request
+const TenantHeader = "X-Tenant-ID"
+
+var (
+	errInvalidTenant  = fiber.NewError(fiber.StatusBadRequest, "Invalid tenant ID")
+	errTenantRequired = fiber.NewError(fiber.StatusBadRequest, "Tenant ID is required")
+	errTenantMismatch = fiber.NewError(fiber.StatusForbidden, "Credentials are not valid for this tenant")
+	errTenantUnbound  = fiber.NewError(fiber.StatusForbidden, "Credentials are not bound to a tenant")
+)
+
+// Tenant middleware stores the tenant of the request: the tenant the
+// principal is bound to, else the one named in header if the principal is
+// bound to tenant.All or the request is anonymous, else defaultTenant.
+// Principals bound to no tenant, and naming another tenant than the one a
+// principal is bound to, get 403. With no tenant, authenticated requests get
+// 400; anonymous ones are passed on without one, for RequireScope to reject.
+func Tenant(header, defaultTenant string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		principal, authenticated := auth.FromContext(ctx)
+
+		requested := c.Get(header)
+		if requested != "" && !tenant.Valid(requested) {
+			return errInvalidTenant
+		}
+
+		id := defaultTenant
+		switch {
+		case !authenticated || principal.Tenant == tenant.All:
+			if requested != "" {
+				id = requested
+			}
+		case principal.Tenant == "":
+			return errTenantUnbound
+		default:
+			id = principal.Tenant
+			if requested != "" && requested != id {
+				return errTenantMismatch
+			}
+		}
+
+		if id == "" {
+			if authenticated {
+				return errTenantRequired
+			}
+			return c.Next()
+		}
+
+		ctx = tenant.WithID(ctx, id)
+		ctx = logger.WithContext(ctx, logger.FromContext(ctx).With(zap.String("tenant", id)))
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/auth"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenant(t *testing.T) {
+	keys := &fakeAPIKeys{principals: map[string]auth.Principal{
+		"operator": {Subject: "apikey:operator", Scopes: []auth.Scope{auth.ScopeUsersRead}, Tenant: tenant.All},
+		"unbound":  {Subject: "token:unbound", Scopes: []auth.Scope{auth.ScopeUsersRead}},
+		"acme":     {Subject: "apikey:acme", Scopes: []auth.Scope{auth.ScopeUsersRead}, Tenant: "acme"},
+	}}
+
+	newApp := func(defaultTenant string) *fiber.App {
+		app := fiber.New()
+		app.Use(Authenticate(keys, nil), Tenant(TenantHeader, defaultTenant))
+		app.Get("/tenant", func(c *fiber.Ctx) error {
+			id, ok := tenant.FromContext(c.UserContext())
+			if !ok {
+				id = "none"
+			}
+			return c.SendString(id)
+		})
+		return app
+	}
+
+	tests := []struct {
+		name          string
+		defaultTenant string
+		key           string
+		header        string
+		status        int
+		tenant        string
+	}{
+		{name: "Default tenant", defaultTenant: tenant.Default, key: "operator", status: fiber.StatusOK, tenant: tenant.Default},
+		{name: "Tenant from header", defaultTenant: tenant.Default, key: "operator", header: "globex", status: fiber.StatusOK, tenant: "globex"},
+		{name: "Tenant from key", defaultTenant: tenant.Default, key: "acme", status: fiber.StatusOK, tenant: "acme"},
+		{name: "Header matching key", defaultTenant: tenant.Default, key: "acme", header: "acme", status: fiber.StatusOK, tenant: "acme"},
+		{name: "Header not matching key", defaultTenant: tenant.Default, key: "acme", header: "globex", status: fiber.StatusForbidden},
+		{name: "Invalid header", defaultTenant: tenant.Default, key: "operator", header: tenant.All, status: fiber.StatusBadRequest},
+		{name: "Tenant required", key: "operator", status: fiber.StatusBadRequest},
+		{name: "Unbound credentials", defaultTenant: tenant.Default, key: "unbound", status: fiber.StatusForbidden},
+		{name: "Header naming the default for unbound credentials", defaultTenant: tenant.Default, key: "unbound", header: tenant.Default, status: fiber.StatusForbidden},
+		{name: "Unbound credentials without default", key: "unbound", status: fiber.StatusForbidden},
+		{name: "Anonymous tenant from header", defaultTenant: tenant.Default, header: "globex", status: fiber.StatusOK, tenant: "globex"},
+		{name: "Anonymous without tenant", status: fiber.StatusOK, tenant: "none"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, "/tenant", nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			if tt.header != "" {
+				req.Header.Set(TenantHeader, tt.header)
+			}
+			resp, err := newApp(tt.defaultTenant).Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			if tt.status == fiber.StatusOK {
+				body, _ := io.ReadAll(resp.Body)
+				assert.Equal(t, tt.tenant, string(body))
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Package middleware provides HTTP middleware functions.
+Tracing middleware continues the trace of an incoming W3C traceparent header,
+or starts a new one, and wraps the request in a server span named after the
+matched route. The span is stored in the request's user context so service
+and SQL spans become its children.
+*/
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing middleware creates a span per request. It renders errors itself,
+// like Metrics, so the span records the final status; register it right
+// after RequestID.
+func Tracing() fiber.Handler {
+	var routes routeIndex
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), requestHeaderCarrier{c})
+		ctx, span := tracing.Tracer().Start(ctx, c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.URLPath(c.Path()),
+			),
+		)
+		// Streamed responses end the span once they are written
+		defer afterStream(c, func() { span.End() })
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+		if err != nil {
+			if handlerErr := c.App().Config().ErrorHandler(c, err); handlerErr != nil {
+				c.Status(fiber.StatusInternalServerError)
+			}
+		}
+
+		// Unmatched requests keep the bare method as span name
+		if path, ok := routes.matched(c); ok {
+			span.SetName(c.Method() + " " + path)
+			span.SetAttributes(semconv.HTTPRoute(path))
+		}
+
+		status := c.Response().StatusCode()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+			if err != nil {
+				span.RecordError(err)
+			}
+		}
+		return nil
+	}
+}
+
+// requestHeaderCarrier exposes the request headers to OpenTelemetry propagators
+type requestHeaderCarrier struct {
+	c *fiber.Ctx
+}
+
+func (h requestHeaderCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+func (h requestHeaderCarrier) Set(key, value string) {
+	h.c.Request().Header.Set(key, value)
+}
+
+func (h requestHeaderCarrier) Keys() []string {
+	keys := make([]string, 0)
+	h.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
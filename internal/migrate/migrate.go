@@ -0,0 +1,270 @@
+/*
+Package migrate applies the SQL migrations embedded from db/migrations and
+records the current version in a schema_migrations table. The table has the
+same layout as golang-migrate's, so databases migrated by hand with that tool
+carry on where they left off.
+Every change holds a Postgres advisory lock, so instances started together
+with MIGRATE_ON_START do not race each other.
+*/
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"go.uber.org/zap"
+)
+
+// NilVersion is the version of a database without any migration applied
+const NilVersion int64 = -1
+
+// lockID is the pg_advisory_lock key shared by every instance of the API
+const lockID int64 = 7_362_094_517
+
+// pgUndefinedTable is reported when schema_migrations does not exist yet
+const pgUndefinedTable = "42P01"
+
+var (
+	// ErrDirty means a migration failed half way. Fix the schema by hand, then
+	// use Force to record the version it is actually at.
+	ErrDirty = errors.New("database is dirty, fix it and force a version")
+	// ErrUnknownVersion means the database is at a version with no migration file
+	ErrUnknownVersion = errors.New("unknown migration version")
+)
+
+const createVersionTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version BIGINT NOT NULL PRIMARY KEY,
+	dirty BOOLEAN NOT NULL
+)`
+
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// Status is the database version and which migrations it includes
+type Status struct {
+	Version    int64
+	Dirty      bool
+	Migrations []MigrationStatus
+}
+
+type MigrationStatus struct {
+	Version int64
+	Name    string
+	Applied bool
+}
+
+// New loads the migrations in fsys; see Load for the file naming scheme
+func New(pool *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// Latest returns the version of the newest migration, or NilVersion if there are none
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return NilVersion
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the current database version without taking the lock
+func (m *Migrator) Version(ctx context.Context) (int64, bool, error) {
+	return readVersion(ctx, m.pool)
+}
+
+// Status reports the database version and every known migration
+func (m *Migrator) Status(ctx context.Context) (Status, error) {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return Status{}, err
+	}
+
+	status := Status{Version: version, Dirty: dirty}
+	for _, mig := range m.migrations {
+		status.Migrations = append(status.Migrations, MigrationStatus{
+			Version: mig.Version,
+			Name:    mig.Name,
+			// A dirty version was not applied cleanly
+			Applied: mig.Version < version || (mig.Version == version && !dirty),
+		})
+	}
+	return status, nil
+}
+
+// Up applies every pending migration and returns how many were applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		next, err := m.position(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations[next:] {
+			if err := apply(ctx, conn, mig.Up, mig.Version); err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", mig.Version, mig.Name, err)
+			}
+			logger.FromContext(ctx).Info("Applied migration",
+				zap.Int64("version", mig.Version),
+				zap.String("name", mig.Name),
+			)
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps migrations, or all of them if steps is not
+// positive, and returns how many were reverted
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	reverted := 0
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		next, err := m.position(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := next - 1; i >= 0 && (steps <= 0 || reverted < steps); i-- {
+			mig := m.migrations[i]
+			if mig.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", mig.Version, mig.Name)
+			}
+
+			target := NilVersion
+			if i > 0 {
+				target = m.migrations[i-1].Version
+			}
+			if err := apply(ctx, conn, mig.Down, target); err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", mig.Version, mig.Name, err)
+			}
+			logger.FromContext(ctx).Info("Reverted migration",
+				zap.Int64("version", mig.Version),
+				zap.String("name", mig.Name),
+			)
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Force records version as applied and clears the dirty flag, without
+// running any migration
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if version != NilVersion && m.index(version) < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+	return m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			return setVersion(ctx, tx, version, false)
+		})
+	})
+}
+
+// withLock runs fn on a single connection holding the migration lock
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return err
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+
+	if _, err := conn.Exec(ctx, createVersionTable); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// position returns the index of the first migration not yet applied
+func (m *Migrator) position(ctx context.Context, conn *pgxpool.Conn) (int, error) {
+	version, dirty, err := readVersion(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w (version %d)", ErrDirty, version)
+	}
+	if version == NilVersion {
+		return 0, nil
+	}
+
+	i := m.index(version)
+	if i < 0 {
+		return 0, fmt.Errorf("%w: database is at %d", ErrUnknownVersion, version)
+	}
+	return i + 1, nil
+}
+
+func (m *Migrator) index(version int64) int {
+	for i, mig := range m.migrations {
+		if mig.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// apply runs one migration in a transaction. The target version is first
+// recorded as dirty outside of it, so a failure is visible afterwards.
+func apply(ctx context.Context, conn *pgxpool.Conn, sql string, target int64) error {
+	err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		return setVersion(ctx, tx, target, true)
+	})
+	if err != nil {
+		return err
+	}
+
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			return err
+		}
+		return setVersion(ctx, tx, target, false)
+	})
+}
+
+func setVersion(ctx context.Context, tx pgx.Tx, version int64, dirty bool) error {
+	if _, err := tx.Exec(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+	if version == NilVersion {
+		return nil
+	}
+	_, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)", version, dirty)
+	return err
+}
+
+func readVersion(ctx context.Context, q interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}) (int64, bool, error) {
+	var version int64
+	var dirty bool
+	err := q.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return NilVersion, false, nil
+	case errors.As(err, &pgErr) && pgErr.Code == pgUndefinedTable:
+		return NilVersion, false, nil
+	case err != nil:
+		return 0, false, err
+	}
+	return version, dirty, nil
+}
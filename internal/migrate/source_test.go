@@ -0,0 +1,50 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/rohanparmar/go-user-api/db/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadOrdersMigrationsByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"2_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD email TEXT;")},
+		"2_add_email.down.sql":    {Data: []byte("ALTER TABLE users DROP email;")},
+		"10_add_index.up.sql":     {Data: []byte("CREATE INDEX idx ON users (email);")},
+		"1_create_users.up.sql":   {Data: []byte("CREATE TABLE users ();")},
+		"1_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"embed.go":                {Data: []byte("package migrations")},
+	}
+
+	migrations, err := Load(fsys)
+	assert.NoError(t, err)
+	assert.Equal(t, []Migration{
+		{Version: 1, Name: "create_users", Up: "CREATE TABLE users ();", Down: "DROP TABLE users;"},
+		{Version: 2, Name: "add_email", Up: "ALTER TABLE users ADD email TEXT;", Down: "ALTER TABLE users DROP email;"},
+		{Version: 10, Name: "add_index", Up: "CREATE INDEX idx ON users (email);"},
+	}, migrations)
+}
+
+func TestLoadRejectsInconsistentMigrations(t *testing.T) {
+	_, err := Load(fstest.MapFS{
+		"1_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	})
+	assert.ErrorContains(t, err, "has no up file")
+
+	_, err = Load(fstest.MapFS{
+		"1_create_users.up.sql":    {Data: []byte("CREATE TABLE users ();")},
+		"1_create_people.down.sql": {Data: []byte("DROP TABLE people;")},
+	})
+	assert.ErrorContains(t, err, "has two names")
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	loaded, err := Load(migrations.FS)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, loaded)
+	for _, m := range loaded {
+		assert.NotEmpty(t, m.Down, "migration %d_%s", m.Version, m.Name)
+	}
+}
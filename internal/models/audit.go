@@ -0,0 +1,31 @@
+package models
+
+// UserSnapshot is the state of a user stored in the audit trail
+type UserSnapshot struct {
+	ID        int32   `json:"id"`
+	Name      string  `json:"name"`
+	DOB       string  `json:"dob"`
+	Version   int32   `json:"version"`
+	DeletedAt *string `json:"deleted_at,omitempty"`
+}
+
+// UserAuditEntry represents a single change in a user's history
+type UserAuditEntry struct {
+	ID        int64         `json:"id"`
+	UserID    int32         `json:"user_id"`
+	Operation string        `json:"operation"`
+	Actor     string        `json:"actor"`
+	RequestID string        `json:"request_id,omitempty"`
+	Before    *UserSnapshot `json:"before"`
+	After     *UserSnapshot `json:"after"`
+	ChangedAt string        `json:"changed_at"`
+}
+
+// UserHistoryResponse represents the response for a user's history with pagination
+type UserHistoryResponse struct {
+	Data       []UserAuditEntry `json:"data"`
+	Total      int64            `json:"total"`
+	Page       int              `json:"page"`
+	Limit      int              `json:"limit"`
+	TotalPages int              `json:"total_pages"`
+}
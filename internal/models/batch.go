@@ -0,0 +1,62 @@
+package models
+
+// Batch modes. Atomic batches are all-or-nothing; best-effort batches commit
+// every operation that succeeds.
+const (
+	BatchModeAtomic     = "atomic"
+	BatchModeBestEffort = "best_effort"
+)
+
+// Batch operation types
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+// BatchRequest represents the request body of POST /users:batch
+type BatchRequest struct {
+	Mode       string                  `json:"mode" validate:"omitempty,oneof=atomic best_effort"`
+	Operations []BatchOperationRequest `json:"operations" validate:"required,min=1"`
+}
+
+// BatchOperationRequest is a single operation of a batch request. User is
+// validated with the same rules as CreateUserRequest.
+type BatchOperationRequest struct {
+	Op      string             `json:"op" validate:"required,oneof=create update delete"`
+	ID      int32              `json:"id" validate:"required_unless=Op create,omitempty,min=1"`
+	User    *CreateUserRequest `json:"user" validate:"required_unless=Op delete"`
+	IfMatch *int32             `json:"if_match"`
+}
+
+// BatchOperation is a batch operation passed to the service and repository
+// layers. ID is unused for creates, Name and DOB for deletes.
+// IfMatch holds the acceptable row versions (nil means unconditional).
+// Invalid is set when the request failed validation; the operation is then
+// reported as failed with that error and never applied.
+type BatchOperation struct {
+	Op      string
+	ID      int32
+	Name    string
+	DOB     string
+	IfMatch []int32
+	Invalid error
+}
+
+// BatchItemResult is the outcome of one operation, in request order
+type BatchItemResult struct {
+	Index  int             `json:"index"`
+	Op     string          `json:"op"`
+	Status int             `json:"status"`
+	User   *UserResponse   `json:"user,omitempty"`
+	Error  *ProblemDetails `json:"error,omitempty"`
+}
+
+// BatchResponse represents the response of POST /users:batch
+type BatchResponse struct {
+	Mode      string            `json:"mode"`
+	Committed bool              `json:"committed"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
@@ -0,0 +1,21 @@
+package models
+
+// Health statuses reported by /healthz and /readyz
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusFailing  = "failing"
+	HealthStatusDraining = "draining"
+)
+
+// HealthResponse is the body of /healthz and /readyz. Checks is only set by
+// /readyz and is keyed by check name.
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult is the outcome of a single readiness check. /readyz is public,
+// so why a check failed is only logged.
+type CheckResult struct {
+	Status string `json:"status"`
+}
@@ -0,0 +1,45 @@
+package models
+
+// Duplicate handling modes of POST /users/import. A row is a duplicate when an
+// active user or an earlier row has the same name (case-insensitive) and dob.
+const (
+	ImportDuplicatesError = "error" // report duplicates as row errors
+	ImportDuplicatesSkip  = "skip"  // import everything except duplicates
+	ImportDuplicatesAllow = "allow" // import duplicates as new users
+)
+
+// ImportRow is a user read from an import file. Err is set when the row could
+// not be parsed or failed validation. Line is the 1-based line in the file.
+type ImportRow struct {
+	Line int
+	Name string
+	DOB  string
+	Err  error
+}
+
+// ImportOptions controls an import
+type ImportOptions struct {
+	DryRun     bool
+	Duplicates string
+}
+
+// ImportRowError describes why a row was rejected
+type ImportRowError struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportReport is the response of POST /users/import. Imported counts the
+// users inserted, or that would have been inserted in a dry run. Errors lists
+// at most the first 100 rejected rows.
+type ImportReport struct {
+	DryRun          bool             `json:"dry_run"`
+	Committed       bool             `json:"committed"`
+	Rows            int              `json:"rows"`
+	Imported        int64            `json:"imported"`
+	Skipped         int              `json:"skipped"`
+	Failed          int              `json:"failed"`
+	Errors          []ImportRowError `json:"errors"`
+	ErrorsTruncated bool             `json:"errors_truncated,omitempty"`
+}
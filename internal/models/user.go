@@ -18,19 +18,76 @@ type UpdateUserRequest struct {
 	DOB  string `json:"dob" validate:"required"`
 }
 
+// PatchUserRequest represents the fields of a partial update (PATCH).
+// Fields left nil are not changed.
+type PatchUserRequest struct {
+	Name *string `json:"name" validate:"omitempty,min=2,max=100"`
+	DOB  *string `json:"dob" validate:"omitempty"`
+}
+
+// UserPatch is a partial update passed to the service and repository layers.
+// TestName and TestDOB are preconditions (JSON Patch "test" operations) that
+// must match the stored values for the update to be applied. IfMatch holds the
+// acceptable row versions from If-Match (nil means unconditional).
+type UserPatch struct {
+	Name     *string
+	DOB      *string
+	TestName *string
+	TestDOB  *string
+	IfMatch  []int32
+}
+
 // UserResponse represents the response for a single user
 type UserResponse struct {
-	ID   int32  `json:"id"`
-	Name string `json:"name"`
-	DOB  string `json:"dob"`
-	Age  *int   `json:"age,omitempty"` // Optional, only for GET requests
+	ID        int32   `json:"id"`
+	Name      string  `json:"name"`
+	DOB       string  `json:"dob"`
+	Age       *int    `json:"age,omitempty"`        // Optional, only for GET requests
+	DeletedAt *string `json:"deleted_at,omitempty"` // Only set for soft-deleted users in admin listings
 }
 
-// UsersListResponse represents the response for listing users with pagination
+// ListUsersQuery represents the query parameters for listing users
+// Cursor selects keyset pagination: nil uses page/limit, "" starts from the
+// first user and any other value continues from a previous next_cursor.
+// Filters are raw query parameter values, validated by the service.
+type ListUsersQuery struct {
+	Page           int
+	Limit          int
+	IncludeDeleted bool
+	Cursor         *string
+	IncludeTotal   bool
+
+	NameContains string
+	NamePrefix   string
+	DOBFrom      string // YYYY-MM-DD, inclusive
+	DOBTo        string // YYYY-MM-DD, inclusive
+	MinAge       *int
+	MaxAge       *int
+	CreatedAfter string // RFC 3339 or YYYY-MM-DD
+	Sort         string // e.g. "name,-dob"
+}
+
+// UsersListResponse represents the response for listing users with pagination.
+// Offset mode always sets Total, Page and TotalPages; cursor mode sets
+// NextCursor while more users remain, and Total only when requested.
 type UsersListResponse struct {
 	Data       []UserResponse `json:"data"`
-	Total      int64          `json:"total"`
-	Page       int            `json:"page"`
+	Total      *int64         `json:"total,omitempty"`
+	Page       int            `json:"page,omitempty"`
 	Limit      int            `json:"limit"`
-	TotalPages int            `json:"total_pages"`
+	TotalPages *int           `json:"total_pages,omitempty"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// UserSearchResult is a UserResponse ranked by relevance to a search query
+type UserSearchResult struct {
+	UserResponse
+	Score float32 `json:"score"`
+}
+
+// UserSearchResponse represents the response for a fuzzy name search
+type UserSearchResponse struct {
+	Query string             `json:"query"`
+	Data  []UserSearchResult `json:"data"`
+	Limit int                `json:"limit"`
 }
@@ -0,0 +1,59 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testdata/flat.parquet was written by github.com/xitongsys/parquet-go
+// (Apache License 2.0) with the schema of its "flat" example, 10 rows and
+// SNAPPY compression. Decoding it checks compactReader, and the enum values
+// and field IDs TestWriter relies on, against an independent implementation.
+func TestReferenceFile(t *testing.T) {
+	data, err := os.ReadFile("testdata/flat.parquet")
+	assert.NoError(t, err)
+	assert.Equal(t, fileFormatMagic, string(data[:4]))
+	assert.Equal(t, fileFormatMagic, string(data[len(data)-4:]))
+
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := (&compactReader{buf: data[len(data)-8-footerLen : len(data)-8]}).structure()
+	assert.Equal(t, int64(10), footer[3]) // num_rows
+
+	// SchemaElement: 1 type, 3 repetition_type, 4 name, 5 num_children, 6 converted_type
+	schema := footer[2].([]any)
+	assert.Len(t, schema, 7)
+	root := schema[0].(map[int16]any)
+	assert.Equal(t, int64(6), root[5])
+
+	name := schema[1].(map[int16]any)
+	assert.Equal(t, "name", name[4])
+	assert.Equal(t, int64(ByteArray), name[1])
+	assert.Equal(t, int64(repetitionReq), name[3])
+	assert.Equal(t, int64(convertedTypes[UTF8]), name[6])
+
+	id := schema[3].(map[int16]any)
+	assert.Equal(t, "id", id[4])
+	assert.Equal(t, int64(Int64), id[1])
+
+	day := schema[6].(map[int16]any)
+	assert.Equal(t, "day", day[4])
+	assert.Equal(t, int64(Int32), day[1])
+	assert.Equal(t, int64(convertedTypes[Date]), day[6])
+
+	// ColumnMetaData: 2 encodings, 3 path_in_schema, 4 codec, 5 num_values, 9 data_page_offset
+	rowGroups := footer[4].([]any)
+	assert.Len(t, rowGroups, 1)
+	chunk := rowGroups[0].(map[int16]any)[1].([]any)[0].(map[int16]any)
+	meta := chunk[3].(map[int16]any)
+	assert.Equal(t, []any{"name"}, meta[3])
+	assert.Contains(t, meta[2], int64(encodingPlain))
+	assert.Contains(t, meta[2], int64(encodingRLE))
+	assert.Equal(t, int64(10), meta[5])
+
+	// PageHeader: 1 type
+	page := (&compactReader{buf: data[meta[9].(int64):]}).structure()
+	assert.Equal(t, int64(pageTypeData), page[1])
+}
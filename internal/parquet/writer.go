@@ -0,0 +1,338 @@
+/*
+Package parquet writes Apache Parquet files with a flat schema.
+It supports the few physical and converted types needed to export users,
+PLAIN encoding and GZIP compression, which keeps it free of dependencies.
+Rows are buffered per row group, since Parquet stores each row group by column.
+*/
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Type is a Parquet physical type
+type Type int32
+
+const (
+	Int32     Type = 1
+	Int64     Type = 2
+	ByteArray Type = 6
+)
+
+// ConvertedType annotates how a physical type is interpreted
+type ConvertedType int
+
+const (
+	Plain           ConvertedType = iota // no annotation
+	UTF8                                 // ByteArray holding UTF-8 text
+	Date                                 // Int32 days since the Unix epoch
+	TimestampMillis                      // Int64 milliseconds since the Unix epoch
+)
+
+// convertedTypes maps ConvertedType to its parquet.thrift enum value
+var convertedTypes = map[ConvertedType]int32{
+	UTF8:            0,
+	Date:            6,
+	TimestampMillis: 9,
+}
+
+// Column describes one column of the schema
+type Column struct {
+	Name      string
+	Type      Type
+	Converted ConvertedType
+	Optional  bool
+}
+
+// Enum values from parquet.thrift
+const (
+	encodingPlain   = 0
+	encodingRLE     = 3
+	codecGzip       = 2
+	pageTypeData    = 0
+	repetitionReq   = 0
+	repetitionOpt   = 1
+	fileFormatMagic = "PAR1"
+)
+
+// DefaultRowGroupRows is the number of rows buffered before a row group is written
+const DefaultRowGroupRows = 64 * 1024
+
+// Writer writes rows to a Parquet file. Close must be called to write the footer.
+type Writer struct {
+	w            io.Writer
+	offset       int64
+	columns      []Column
+	chunks       []columnChunk
+	rows         int64
+	totalRows    int64
+	rowGroups    []rowGroup
+	RowGroupRows int64
+}
+
+// columnChunk buffers the values of one column for the current row group
+type columnChunk struct {
+	values bytes.Buffer
+	levels []byte // definition levels, for optional columns
+}
+
+type rowGroup struct {
+	rows    int64
+	size    int64
+	columns []chunkMeta
+}
+
+type chunkMeta struct {
+	offset           int64
+	uncompressedSize int64
+	compressedSize   int64
+}
+
+// NewWriter writes the file header and returns a writer for columns
+func NewWriter(w io.Writer, columns []Column) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("parquet: schema has no columns")
+	}
+	pw := &Writer{
+		w:            w,
+		columns:      columns,
+		chunks:       make([]columnChunk, len(columns)),
+		RowGroupRows: DefaultRowGroupRows,
+	}
+	return pw, pw.write([]byte(fileFormatMagic))
+}
+
+func (pw *Writer) write(p []byte) error {
+	n, err := pw.w.Write(p)
+	pw.offset += int64(n)
+	return err
+}
+
+// Write appends a row with one value per column: int32 for Int32, int64 for
+// Int64 and string for ByteArray. Optional columns also accept nil.
+// An invalid row is rejected as a whole.
+func (pw *Writer) Write(values ...any) error {
+	if len(values) != len(pw.columns) {
+		return fmt.Errorf("parquet: got %d values for %d columns", len(values), len(pw.columns))
+	}
+	for i, value := range values {
+		if err := pw.columns[i].check(value); err != nil {
+			return err
+		}
+	}
+	for i, value := range values {
+		pw.chunks[i].append(pw.columns[i], value)
+	}
+	pw.rows++
+	if pw.rows >= pw.RowGroupRows {
+		return pw.flushRowGroup()
+	}
+	return nil
+}
+
+// check reports whether value can be stored in the column
+func (c Column) check(value any) error {
+	var ok bool
+	switch value.(type) {
+	case nil:
+		ok = c.Optional
+	case int32:
+		ok = c.Type == Int32
+	case int64:
+		ok = c.Type == Int64
+	case string:
+		ok = c.Type == ByteArray
+	}
+	if !ok {
+		return fmt.Errorf("parquet: invalid value %T for column %s", value, c.Name)
+	}
+	return nil
+}
+
+// append adds a checked value using PLAIN encoding
+func (chunk *columnChunk) append(column Column, value any) {
+	if column.Optional {
+		if value == nil {
+			chunk.levels = append(chunk.levels, 0)
+			return
+		}
+		chunk.levels = append(chunk.levels, 1)
+	}
+
+	switch v := value.(type) {
+	case int32:
+		chunk.values.Write(binary.LittleEndian.AppendUint32(nil, uint32(v)))
+	case int64:
+		chunk.values.Write(binary.LittleEndian.AppendUint64(nil, uint64(v)))
+	case string:
+		chunk.values.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(v))))
+		chunk.values.WriteString(v)
+	}
+}
+
+// flushRowGroup writes the buffered rows as one row group with a single data
+// page per column
+func (pw *Writer) flushRowGroup() error {
+	if pw.rows == 0 {
+		return nil
+	}
+	group := rowGroup{rows: pw.rows}
+
+	for i, column := range pw.columns {
+		chunk := &pw.chunks[i]
+
+		var page bytes.Buffer
+		if column.Optional {
+			levels := encodeLevels(chunk.levels)
+			var length [4]byte
+			binary.LittleEndian.PutUint32(length[:], uint32(len(levels)))
+			page.Write(length[:])
+			page.Write(levels)
+		}
+		page.Write(chunk.values.Bytes())
+
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+
+		header := pageHeader(pw.rows, page.Len(), compressed.Len())
+		meta := chunkMeta{
+			offset:           pw.offset,
+			uncompressedSize: int64(len(header) + page.Len()),
+			compressedSize:   int64(len(header) + compressed.Len()),
+		}
+		if err := pw.write(header); err != nil {
+			return err
+		}
+		if err := pw.write(compressed.Bytes()); err != nil {
+			return err
+		}
+
+		group.columns = append(group.columns, meta)
+		group.size += meta.uncompressedSize
+		chunk.values.Reset()
+		chunk.levels = chunk.levels[:0]
+	}
+
+	pw.rowGroups = append(pw.rowGroups, group)
+	pw.totalRows += pw.rows
+	pw.rows = 0
+	return nil
+}
+
+// Close flushes the last row group and writes the file footer. It does not
+// close the underlying writer.
+func (pw *Writer) Close() error {
+	if err := pw.flushRowGroup(); err != nil {
+		return err
+	}
+
+	footer := pw.fileMetaData()
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	if err := pw.write(footer); err != nil {
+		return err
+	}
+	if err := pw.write(length[:]); err != nil {
+		return err
+	}
+	return pw.write([]byte(fileFormatMagic))
+}
+
+// encodeLevels encodes definition levels (bit width 1) with the RLE part of
+// the RLE/bit-packing hybrid encoding, one run per sequence of equal levels
+func encodeLevels(levels []byte) []byte {
+	var out []byte
+	for start := 0; start < len(levels); {
+		end := start + 1
+		for end < len(levels) && levels[end] == levels[start] {
+			end++
+		}
+		out = binary.AppendUvarint(out, uint64(end-start)<<1)
+		out = append(out, levels[start])
+		start = end
+	}
+	return out
+}
+
+// pageHeader encodes a PageHeader for a data page of numValues values
+func pageHeader(numValues int64, uncompressedSize, compressedSize int) []byte {
+	var t thriftWriter
+	t.i32(1, pageTypeData)
+	t.i32(2, int32(uncompressedSize))
+	t.i32(3, int32(compressedSize))
+	t.beginStruct(5) // DataPageHeader
+	t.i32(1, int32(numValues))
+	t.i32(2, encodingPlain)
+	t.i32(3, encodingRLE)
+	t.i32(4, encodingRLE)
+	t.endStruct()
+	t.buf = append(t.buf, 0)
+	return t.buf
+}
+
+// fileMetaData encodes the FileMetaData footer
+func (pw *Writer) fileMetaData() []byte {
+	var t thriftWriter
+	t.i32(1, 1) // version
+
+	t.listHeader(2, compactStruct, len(pw.columns)+1)
+	t.beginElement() // root
+	t.binary(4, "schema")
+	t.i32(5, int32(len(pw.columns)))
+	t.endStruct()
+	for _, column := range pw.columns {
+		t.beginElement()
+		t.i32(1, int32(column.Type))
+		repetition := int32(repetitionReq)
+		if column.Optional {
+			repetition = repetitionOpt
+		}
+		t.i32(3, repetition)
+		t.binary(4, column.Name)
+		if converted, ok := convertedTypes[column.Converted]; ok {
+			t.i32(6, converted)
+		}
+		t.endStruct()
+	}
+
+	t.i64(3, pw.totalRows)
+
+	t.listHeader(4, compactStruct, len(pw.rowGroups))
+	for _, group := range pw.rowGroups {
+		t.beginElement()
+		t.listHeader(1, compactStruct, len(group.columns))
+		for i, chunk := range group.columns {
+			t.beginElement() // ColumnChunk
+			t.i64(2, chunk.offset)
+			t.beginStruct(3) // ColumnMetaData
+			t.i32(1, int32(pw.columns[i].Type))
+			t.i32List(2, encodingPlain, encodingRLE)
+			t.binaryList(3, pw.columns[i].Name)
+			t.i32(4, codecGzip)
+			t.i64(5, group.rows)
+			t.i64(6, chunk.uncompressedSize)
+			t.i64(7, chunk.compressedSize)
+			t.i64(9, chunk.offset)
+			t.endStruct()
+			t.endStruct()
+		}
+		t.i64(2, group.size)
+		t.i64(3, group.rows)
+		t.endStruct()
+	}
+
+	t.binary(6, "go-user-api")
+	t.buf = append(t.buf, 0)
+	return t.buf
+}
@@ -0,0 +1,141 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// compactReader decodes Thrift compact structs into maps keyed by field ID,
+// enough to check the metadata written by Writer
+type compactReader struct {
+	buf []byte
+}
+
+func (r *compactReader) byte() byte {
+	b := r.buf[0]
+	r.buf = r.buf[1:]
+	return b
+}
+
+func (r *compactReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf)
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *compactReader) int() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *compactReader) value(typ byte) any {
+	switch typ {
+	case compactI32, compactI64:
+		return r.int()
+	case compactBinary:
+		n := r.uvarint()
+		s := string(r.buf[:n])
+		r.buf = r.buf[n:]
+		return s
+	case compactList:
+		header := r.byte()
+		size, elem := int(header>>4), header&0x0f
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		list := make([]any, size)
+		for i := range list {
+			list[i] = r.value(elem)
+		}
+		return list
+	case compactStruct:
+		return r.structure()
+	}
+	panic("unexpected thrift type")
+}
+
+func (r *compactReader) structure() map[int16]any {
+	fields := map[int16]any{}
+	var id int16
+	for {
+		header := r.byte()
+		if header == 0 {
+			return fields
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(r.int())
+		}
+		fields[id] = r.value(header & 0x0f)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var file bytes.Buffer
+	pw, err := NewWriter(&file, []Column{
+		{Name: "id", Type: Int32},
+		{Name: "name", Type: ByteArray, Converted: UTF8},
+		{Name: "deleted_at", Type: Int64, Converted: TimestampMillis, Optional: true},
+	})
+	assert.NoError(t, err)
+	pw.RowGroupRows = 2
+
+	assert.NoError(t, pw.Write(int32(1), "Alice", nil))
+	assert.NoError(t, pw.Write(int32(2), "Bob", int64(1700000000000)))
+	assert.NoError(t, pw.Write(int32(3), "Carol", nil))
+	assert.Error(t, pw.Write(int32(4), nil, nil))
+	assert.Error(t, pw.Write("5", "Dave", nil))
+	assert.NoError(t, pw.Close())
+
+	data := file.Bytes()
+	assert.Equal(t, "PAR1", string(data[:4]))
+	assert.Equal(t, "PAR1", string(data[len(data)-4:]))
+
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := (&compactReader{buf: data[len(data)-8-footerLen : len(data)-8]}).structure()
+
+	assert.Equal(t, int64(3), footer[3]) // num_rows
+	schema := footer[2].([]any)
+	assert.Len(t, schema, 4)
+	assert.Equal(t, int64(3), schema[0].(map[int16]any)[5])
+	assert.Equal(t, "deleted_at", schema[3].(map[int16]any)[4])
+	assert.Equal(t, int64(1), schema[3].(map[int16]any)[3]) // OPTIONAL
+
+	rowGroups := footer[4].([]any)
+	assert.Len(t, rowGroups, 2)
+	assert.Equal(t, int64(2), rowGroups[0].(map[int16]any)[3])
+	assert.Equal(t, int64(1), rowGroups[1].(map[int16]any)[3])
+
+	// Rejected rows leave no values behind
+	lastIDs := readPage(t, data, rowGroups[1].(map[int16]any)[1].([]any)[0].(map[int16]any))
+	assert.Equal(t, []byte{3, 0, 0, 0}, lastIDs)
+
+	// Decode the deleted_at page of the first row group
+	chunk := rowGroups[0].(map[int16]any)[1].([]any)[2].(map[int16]any)
+	assert.Equal(t, []any{"deleted_at"}, chunk[3].(map[int16]any)[3])
+	values := readPage(t, data, chunk)
+
+	levelsLen := binary.LittleEndian.Uint32(values)
+	assert.Equal(t, []byte{1 << 1, 0, 1 << 1, 1}, values[4:4+levelsLen])
+	assert.Equal(t, uint64(1700000000000), binary.LittleEndian.Uint64(values[4+levelsLen:]))
+}
+
+// readPage returns the uncompressed data page of a column chunk
+func readPage(t *testing.T, data []byte, chunk map[int16]any) []byte {
+	meta := chunk[3].(map[int16]any)
+	page := &compactReader{buf: data[meta[9].(int64):]}
+	header := page.structure()
+
+	zr, err := gzip.NewReader(bytes.NewReader(page.buf[:header[3].(int64)]))
+	assert.NoError(t, err)
+	values, err := io.ReadAll(zr)
+	assert.NoError(t, err)
+	assert.Equal(t, header[2], int64(len(values)))
+	return values
+}
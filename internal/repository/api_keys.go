@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+)
+
+// APIKeyRepository stores API keys. Only a hash of each key is kept, looked
+// up by the key's public prefix, with the tenant the key acts for. Revoke and
+// Expire return ErrNotFound when the key does not exist or is already revoked.
+type APIKeyRepository interface {
+	Create(ctx context.Context, name, tenantID, prefix string, keyHash []byte, scopes []string, expiresAt time.Time) (db.ApiKey, error)
+	Get(ctx context.Context, id int32) (db.ApiKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (db.ApiKey, error)
+	List(ctx context.Context) ([]db.ApiKey, error)
+	Revoke(ctx context.Context, id int32) error
+	Expire(ctx context.Context, id int32, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	queries *db.Queries
+}
+
+func NewAPIKeyRepository(pool *pgxpool.Pool) APIKeyRepository {
+	return &apiKeyRepository{queries: db.New(pool)}
+}
+
+// Create stores a new key; a zero expiresAt means the key does not expire and
+// a tenantID of tenant.All that it may act for any tenant
+func (r *apiKeyRepository) Create(ctx context.Context, name, tenantID, prefix string, keyHash []byte, scopes []string, expiresAt time.Time) (db.ApiKey, error) {
+	key, err := r.queries.CreateAPIKey(ctx, db.CreateAPIKeyParams{
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   keyHash,
+		Scopes:    scopes,
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: !expiresAt.IsZero()},
+		TenantID:  tenantID,
+	})
+	return key, translateError(err)
+}
+
+func (r *apiKeyRepository) Get(ctx context.Context, id int32) (db.ApiKey, error) {
+	key, err := r.queries.GetAPIKey(ctx, id)
+	return key, translateError(err)
+}
+
+func (r *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (db.ApiKey, error) {
+	key, err := r.queries.GetAPIKeyByPrefix(ctx, prefix)
+	return key, translateError(err)
+}
+
+func (r *apiKeyRepository) List(ctx context.Context) ([]db.ApiKey, error) {
+	keys, err := r.queries.ListAPIKeys(ctx)
+	return keys, translateError(err)
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id int32) error {
+	count, err := r.queries.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return translateError(err)
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Expire brings the expiry of a key forward to at. A key that already expires
+// earlier is left unchanged, which is not an error.
+func (r *apiKeyRepository) Expire(ctx context.Context, id int32, at time.Time) error {
+	_, err := r.queries.ExpireAPIKey(ctx, db.ExpireAPIKeyParams{
+		ID:        id,
+		ExpiresAt: pgtype.Timestamptz{Time: at, Valid: true},
+	})
+	return translateError(err)
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/rohanparmar/go-user-api/internal/audit"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+)
+
+// recordAudit writes a user_audit row using the tenant, actor and request ID
+// from ctx.
+// It must be called with the transaction-bound queries of the change itself.
+func recordAudit(ctx context.Context, q *db.Queries, operation string, before, after *db.User) error {
+	row, err := newAuditRow(ctx, operation, before, after)
+	if err != nil {
+		return err
+	}
+	return q.InsertUserAudit(ctx, row)
+}
+
+// newAuditRow builds the user_audit row describing a change
+func newAuditRow(ctx context.Context, operation string, before, after *db.User) (db.InsertUserAuditParams, error) {
+	var userID int32
+	if after != nil {
+		userID = after.ID
+	} else if before != nil {
+		userID = before.ID
+	}
+
+	beforeJSON, err := snapshotJSON(before)
+	if err != nil {
+		return db.InsertUserAuditParams{}, err
+	}
+	afterJSON, err := snapshotJSON(after)
+	if err != nil {
+		return db.InsertUserAuditParams{}, err
+	}
+
+	return db.InsertUserAuditParams{
+		TenantID:  tenant.ID(ctx),
+		UserID:    userID,
+		Operation: operation,
+		Actor:     audit.Actor(ctx),
+		RequestID: audit.RequestID(ctx),
+		Before:    beforeJSON,
+		After:     afterJSON,
+	}, nil
+}
+
+// snapshotJSON encodes a user as a JSONB audit snapshot (NULL when nil)
+func snapshotJSON(user *db.User) ([]byte, error) {
+	if user == nil {
+		return nil, nil
+	}
+	return json.Marshal(snapshot(*user))
+}
+
+// snapshot converts a user row into its audit snapshot representation
+func snapshot(user db.User) models.UserSnapshot {
+	result := models.UserSnapshot{
+		ID:      user.ID,
+		Name:    user.Name,
+		DOB:     user.Dob.Time.Format("2006-01-02"),
+		Version: user.Version,
+	}
+	if user.DeletedAt.Valid {
+		deletedAt := user.DeletedAt.Time.UTC().Format(time.RFC3339)
+		result.DeletedAt = &deletedAt
+	}
+	return result
+}
+
+func (r *userRepository) History(ctx context.Context, userID int32, limit, offset int32) ([]db.UserAudit, error) {
+	entries, err := r.queries.ListUserAudit(ctx, db.ListUserAuditParams{
+		TenantID: tenant.ID(ctx),
+		UserID:   userID,
+		Limit:    limit,
+		Offset:   offset,
+	})
+	return entries, translateError(err)
+}
+
+func (r *userRepository) CountHistory(ctx context.Context, userID int32) (int64, error) {
+	count, err := r.queries.CountUserAudit(ctx, db.CountUserAuditParams{
+		TenantID: tenant.ID(ctx),
+		UserID:   userID,
+	})
+	return count, translateError(err)
+}
+
+func (r *userRepository) GetAsOf(ctx context.Context, userID int32, asOf time.Time) (db.UserAudit, error) {
+	entry, err := r.queries.GetUserAuditAsOf(ctx, db.GetUserAuditAsOfParams{
+		TenantID: tenant.ID(ctx),
+		UserID:   userID,
+		ChangedAt: pgtype.Timestamptz{
+			Time:  asOf,
+			Valid: true,
+		},
+	})
+	return entry, translateError(err)
+}
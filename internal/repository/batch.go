@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/rohanparmar/go-user-api/internal/audit"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+	"go.uber.org/zap"
+)
+
+// BatchResult is the outcome of one operation passed to Batch. Err is nil
+// both for applied operations and, when an atomic batch fails, for the
+// operations that were rolled back with it.
+type BatchResult struct {
+	User db.User
+	Err  error
+}
+
+// maxBatchReplays bounds how many times a best-effort batch resends its
+// pending operations after a failure, keeping it linear in the batch size
+const maxBatchReplays = 8
+
+// batchOperations maps batch operation types to their audit operation
+var batchOperations = map[string]string{
+	models.BatchOpCreate: audit.OperationCreate,
+	models.BatchOpUpdate: audit.OperationUpdate,
+	models.BatchOpDelete: audit.OperationDelete,
+}
+
+// Batch applies ops in one transaction. Statements are pipelined with
+// pgx.Batch and the audit rows are written with a single COPY.
+//
+// Missing users and version mismatches are found up front from the locked
+// rows, so they never reach the pipeline.
+//
+// In atomic mode the first failing operation rolls back the whole batch and
+// the returned results only carry that failure. Otherwise each database error
+// rolls back to a savepoint, the failing operation is dropped and the rest are
+// replayed, so every operation that can succeed is committed. Each replay
+// resends every pending operation, so after maxBatchReplays failures the rest
+// are applied one at a time, each in its own savepoint.
+// Ops are expected to be valid and to touch each user at most once.
+func (r *userRepository) Batch(ctx context.Context, ops []models.BatchOperation, atomic bool) ([]BatchResult, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer tx.Rollback(ctx) // no-op once committed
+
+	q := r.queries.WithTx(tx)
+	before, err := lockBatchUsers(ctx, q, ops)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	results := make([]BatchResult, len(ops))
+	pending := make([]int, 0, len(ops))
+	for i, op := range ops {
+		if op.Op != models.BatchOpCreate {
+			user, ok := before[op.ID]
+			if !ok || user.DeletedAt.Valid || (op.IfMatch != nil && !slices.Contains(op.IfMatch, user.Version)) {
+				results[i].Err = ErrNotFound
+				if atomic {
+					return batchFailure(results, i), nil
+				}
+				continue
+			}
+		}
+		pending = append(pending, i)
+	}
+
+	replays := 0
+	for len(pending) > 0 {
+		chunk := pending
+		if replays >= maxBatchReplays {
+			chunk = pending[:1]
+		}
+		sp, err := tx.Begin(ctx) // savepoint
+		if err != nil {
+			return nil, translateError(err)
+		}
+		failed, err := applyBatch(ctx, q.WithTx(sp), ops, chunk, results)
+		if err != nil {
+			return nil, translateError(err)
+		}
+		if failed < 0 {
+			if err := sp.Commit(ctx); err != nil {
+				return nil, translateError(err)
+			}
+			pending = pending[len(chunk):]
+			continue
+		}
+		if atomic {
+			return batchFailure(results, failed), nil
+		}
+		if err := sp.Rollback(ctx); err != nil {
+			return nil, translateError(err)
+		}
+		logger.FromContext(ctx).Debug("Batch operation failed, replaying the others",
+			zap.Int("index", failed),
+			zap.Error(results[failed].Err),
+		)
+		pending = slices.DeleteFunc(pending, func(i int) bool { return i == failed })
+		replays++
+	}
+
+	// Not found and version mismatches do not abort the transaction
+	if atomic {
+		for i := range results {
+			if results[i].Err != nil {
+				return batchFailure(results, i), nil
+			}
+		}
+	}
+
+	rows := make([]db.InsertUserAuditsParams, 0, len(ops))
+	for i, op := range ops {
+		if results[i].Err != nil {
+			continue
+		}
+		var prior *db.User
+		if user, ok := before[op.ID]; ok && op.Op != models.BatchOpCreate {
+			prior = &user
+		}
+		row, err := newAuditRow(ctx, batchOperations[op.Op], prior, &results[i].User)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, db.InsertUserAuditsParams(row))
+	}
+	if len(rows) > 0 {
+		if _, err := q.InsertUserAudits(ctx, rows); err != nil {
+			return nil, translateError(err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, translateError(err)
+	}
+	return results, nil
+}
+
+// lockBatchUsers locks the users updated or deleted by ops, keyed by ID
+func lockBatchUsers(ctx context.Context, q *db.Queries, ops []models.BatchOperation) (map[int32]db.User, error) {
+	var ids []int32
+	for _, op := range ops {
+		if op.Op != models.BatchOpCreate {
+			ids = append(ids, op.ID)
+		}
+	}
+	users := make(map[int32]db.User, len(ids))
+	if len(ids) == 0 {
+		return users, nil
+	}
+
+	rows, err := q.GetUsersByIDsForUpdate(ctx, db.GetUsersByIDsForUpdateParams{
+		TenantID: tenant.ID(ctx),
+		Ids:      ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range rows {
+		users[user.ID] = user
+	}
+	return users, nil
+}
+
+// applyBatch sends the pending operations as one pipelined batch per
+// operation type and stores their outcome in results. It returns the index of
+// the first operation that failed with a database error (which aborts the
+// transaction), or -1. A missing row is recorded as ErrNotFound and does not
+// count as a failure.
+func applyBatch(ctx context.Context, q *db.Queries, ops []models.BatchOperation, pending []int, results []BatchResult) (int, error) {
+	var creates, updates, deletes []int
+	for _, i := range pending {
+		results[i] = BatchResult{}
+		switch ops[i].Op {
+		case models.BatchOpCreate:
+			creates = append(creates, i)
+		case models.BatchOpUpdate:
+			updates = append(updates, i)
+		case models.BatchOpDelete:
+			deletes = append(deletes, i)
+		}
+	}
+
+	failed := -1
+	collect := func(indexes []int) func(int, db.User, error) {
+		return func(t int, user db.User, err error) {
+			i := indexes[t]
+			switch {
+			case failed >= 0:
+				// The transaction is aborted; later results are meaningless
+			case errors.Is(err, pgx.ErrNoRows):
+				results[i].Err = ErrNotFound
+			case err != nil:
+				results[i].Err = translateError(err)
+				failed = i
+			default:
+				results[i].User = user
+			}
+		}
+	}
+
+	if len(creates) > 0 {
+		params := make([]db.CreateUsersBatchParams, 0, len(creates))
+		for _, i := range creates {
+			params = append(params, db.CreateUsersBatchParams{
+				TenantID: tenant.ID(ctx),
+				Name:     ops[i].Name,
+				Dob:      parsePGDate(ops[i].DOB),
+			})
+		}
+		q.CreateUsersBatch(ctx, params).QueryRow(collect(creates))
+	}
+	if len(updates) > 0 && failed < 0 {
+		params := make([]db.UpdateUsersBatchParams, 0, len(updates))
+		for _, i := range updates {
+			params = append(params, db.UpdateUsersBatchParams{
+				TenantID:         tenant.ID(ctx),
+				ID:               ops[i].ID,
+				Name:             ops[i].Name,
+				Dob:              parsePGDate(ops[i].DOB),
+				ExpectedVersions: ops[i].IfMatch,
+			})
+		}
+		q.UpdateUsersBatch(ctx, params).QueryRow(collect(updates))
+	}
+	if len(deletes) > 0 && failed < 0 {
+		params := make([]db.DeleteUsersBatchParams, 0, len(deletes))
+		for _, i := range deletes {
+			params = append(params, db.DeleteUsersBatchParams{
+				TenantID:         tenant.ID(ctx),
+				ID:               ops[i].ID,
+				ExpectedVersions: ops[i].IfMatch,
+			})
+		}
+		q.DeleteUsersBatch(ctx, params).QueryRow(collect(deletes))
+	}
+
+	if failed >= 0 && !isStatementError(results[failed].Err) {
+		return failed, results[failed].Err
+	}
+	return failed, nil
+}
+
+// isStatementError reports whether err was caused by the operation itself
+// rather than by the connection or server, so other operations may still succeed
+func isStatementError(err error) bool {
+	return errors.Is(err, ErrConflict) || errors.Is(err, ErrInvalid)
+}
+
+// batchFailure reduces the results of a failed atomic batch to the failure
+// of operation i; nothing else was committed
+func batchFailure(results []BatchResult, i int) []BatchResult {
+	failure := results[i].Err
+	clear(results)
+	results[i].Err = failure
+	return results
+}
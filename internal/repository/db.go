@@ -2,24 +2,161 @@ package repository
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rohanparmar/go-user-api/config"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+	"go.uber.org/zap"
 )
 
-func NewPostgresPool(dbURL string) *pgxpool.Pool {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// Backoff between connection attempts at startup: it starts at
+// minRetryBackoff and doubles up to maxRetryBackoff
+const (
+	minRetryBackoff = 250 * time.Millisecond
+	maxRetryBackoff = 5 * time.Second
+)
+
+// dsnQuoter escapes a value quoted in a keyword/value DSN
+var dsnQuoter = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// NewPoolConfig builds a pool configuration from cfg. cfg.URL, when set, is
+// used as is (URL or keyword/value form); otherwise a URL is built from the
+// discrete fields with the user and password escaped. TLS parameters set in
+// cfg override those of the URL.
+func NewPoolConfig(cfg config.DBConfig) (*pgxpool.Config, error) {
+	dsn := cfg.URL
+	if dsn == "" {
+		u := url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(cfg.User, cfg.Password),
+			Host:   net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+			Path:   "/" + cfg.Name,
+		}
+		dsn = u.String()
+	}
+
+	// Only URLs can carry parameters in the query, keyword/value DSNs get them appended
+	params := url.Values{}
+	for key, value := range map[string]string{
+		"sslmode":     cfg.SSLMode,
+		"sslrootcert": cfg.SSLRootCert,
+		"sslcert":     cfg.SSLCert,
+		"sslkey":      cfg.SSLKey,
+	} {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	if len(params) > 0 {
+		u, err := url.Parse(dsn)
+		if err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+			query := u.Query()
+			for key := range params {
+				query.Set(key, params.Get(key))
+			}
+			u.RawQuery = query.Encode()
+			dsn = u.String()
+		} else {
+			for key := range params {
+				dsn += fmt.Sprintf(" %s='%s'", key, dsnQuoter.Replace(params.Get(key)))
+			}
+		}
+	}
 
-	pool, err := pgxpool.New(ctx, dbURL)
+	poolConfig, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
-		log.Fatalf("unable to connect to database: %v", err)
+		// pgconn redacts the password from the connection string in the error
+		return nil, fmt.Errorf("invalid database configuration: %w", err)
 	}
 
-	if err := pool.Ping(ctx); err != nil {
-		log.Fatalf("database ping failed: %v", err)
+	poolConfig.MaxConns = cfg.MaxConns
+	poolConfig.MinConns = cfg.MinConns
+	if cfg.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
 	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	if cfg.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+	if cfg.ConnectTimeout > 0 {
+		poolConfig.ConnConfig.ConnectTimeout = cfg.ConnectTimeout
+	}
+	if cfg.StatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+
+	return poolConfig, nil
+}
+
+// setTenant stores the tenant read by the tenant_isolation row-level security
+// policies for the rest of the session
+const setTenant = "SELECT set_config('app.tenant_id', $1, false)"
 
-	return pool
+// EnableRowLevelSecurity makes every connection taken from the pool act for
+// the tenant of the context it is acquired with (tenant.Default if none), so
+// the tenant_isolation policies of users and user_audit apply once row-level
+// security is enabled on the tables. It costs one round trip per acquire.
+func EnableRowLevelSecurity(poolConfig *pgxpool.Config) {
+	poolConfig.PrepareConn = func(ctx context.Context, conn *pgx.Conn) (bool, error) {
+		// A connection that cannot be switched is dropped, never reused
+		_, err := conn.Exec(ctx, setTenant, tenant.ID(ctx))
+		return err == nil, err
+	}
+}
+
+// NewPostgresPool opens a pool and pings the database, retrying with
+// exponential backoff for up to retryTimeout so the server can start before
+// the database is reachable
+func NewPostgresPool(ctx context.Context, poolConfig *pgxpool.Config, retryTimeout time.Duration) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(retryTimeout)
+	for attempt := 0; ; attempt++ {
+		err = pool.Ping(ctx)
+		if err == nil {
+			return pool, nil
+		}
+
+		wait := retryBackoff(attempt)
+		if time.Now().Add(wait).After(deadline) {
+			break
+		}
+		logger.FromContext(ctx).Warn("Database is not reachable, retrying",
+			zap.Int("attempt", attempt+1),
+			zap.Duration("backoff", wait),
+			zap.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			pool.Close()
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+
+	pool.Close()
+	return nil, fmt.Errorf("database ping failed: %w", err)
+}
+
+// retryBackoff returns the wait before the attempt after the given one
+func retryBackoff(attempt int) time.Duration {
+	wait := minRetryBackoff
+	for i := 0; i < attempt && wait < maxRetryBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxRetryBackoff)
 }
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Sentinel errors returned by the repository layer.
+// Callers should use errors.Is to check for them; the original driver error
+// is kept in the chain for logging.
+var (
+	ErrNotFound    = errors.New("record not found")
+	ErrConflict    = errors.New("record conflicts with existing data")
+	ErrInvalid     = errors.New("record violates a data constraint")
+	ErrUnavailable = errors.New("database unavailable")
+)
+
+// PostgreSQL error codes we translate (see Appendix A of the Postgres docs)
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgNotNullViolation    = "23502"
+	pgCheckViolation      = "23514"
+	pgExclusionViolation  = "23P01"
+	pgQueryCanceled       = "57014"
+	pgAdminShutdown       = "57P01"
+	pgCrashShutdown       = "57P02"
+	pgCannotConnectNow    = "57P03"
+)
+
+// repoError keeps the sentinel for errors.Is while preserving the driver error.
+type repoError struct {
+	kind error
+	err  error
+}
+
+func (e *repoError) Error() string { return e.kind.Error() + ": " + e.err.Error() }
+
+func (e *repoError) Is(target error) bool { return target == e.kind }
+
+func (e *repoError) Unwrap() error { return e.err }
+
+// translateError converts pgx/pgconn errors into repository sentinel errors
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var translated *repoError
+	if errors.As(err, &translated) || errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &repoError{kind: ErrNotFound, err: err}
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation, pgForeignKeyViolation, pgExclusionViolation:
+			return &repoError{kind: ErrConflict, err: err}
+		case pgNotNullViolation, pgCheckViolation:
+			return &repoError{kind: ErrInvalid, err: err}
+		case pgQueryCanceled, pgAdminShutdown, pgCrashShutdown, pgCannotConnectNow:
+			return &repoError{kind: ErrUnavailable, err: err}
+		}
+
+		// Class 08 (connection exception) and 53 (insufficient resources)
+		if strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "53") {
+			return &repoError{kind: ErrUnavailable, err: err}
+		}
+		return err
+	}
+
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		pgconn.Timeout(err) {
+		return &repoError{kind: ErrUnavailable, err: err}
+	}
+
+	return err
+}
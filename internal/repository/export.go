@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+)
+
+// exportFetchSize is the number of rows fetched per round trip by Export
+const exportFetchSize = 500
+
+// Export calls fn for every user matching filter, in sort order. Rows are read
+// in chunks from a server-side cursor in a read-only REPEATABLE READ
+// transaction, so the export is a consistent snapshot and memory use does not
+// grow with the table. An error returned by fn stops the export and is
+// returned as is.
+func (r *userRepository) Export(ctx context.Context, filter UserFilter, sort []SortField, fn func(db.User) error) error {
+	q := newUserQuery(ctx, filter)
+	order, err := orderBy(sort)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return translateError(err)
+	}
+	defer tx.Rollback(ctx) // read-only, nothing to commit
+
+	declare := "DECLARE user_export NO SCROLL CURSOR FOR SELECT " + userColumns + " FROM users" + q.whereClause() + order
+	if _, err := tx.Exec(ctx, declare, q.args...); err != nil {
+		return translateError(err)
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM user_export", exportFetchSize)
+	for {
+		rows, err := tx.Query(ctx, fetch)
+		if err != nil {
+			return translateError(err)
+		}
+		users, err := pgx.CollectRows(rows, scanUser)
+		if err != nil {
+			return translateError(err)
+		}
+
+		for _, user := range users {
+			if err := fn(user); err != nil {
+				return err
+			}
+		}
+		if len(users) < exportFetchSize {
+			return nil
+		}
+	}
+}
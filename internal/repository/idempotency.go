@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+)
+
+// IdempotencyRepository stores Idempotency-Key records, scoped by actor.
+// Claim reserves a key for a new request; it returns the stored record and
+// false when the key is already held by an earlier request that has not expired.
+// A claimed key is either completed with the response to replay, or released
+// so that the request can be retried. A claim neither completed nor released
+// within lockTimeout (e.g. the server stopped mid-request) can be taken over
+// by a retry of the same request.
+type IdempotencyRepository interface {
+	Claim(ctx context.Context, actor, key string, requestHash []byte, expiresAt time.Time, lockTimeout time.Duration) (db.IdempotencyKey, bool, error)
+	Complete(ctx context.Context, actor, key string, status int32, headers, body []byte) error
+	Release(ctx context.Context, actor, key string) error
+	PurgeExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type idempotencyRepository struct {
+	queries *db.Queries
+}
+
+func NewIdempotencyRepository(pool *pgxpool.Pool) IdempotencyRepository {
+	return &idempotencyRepository{queries: db.New(pool)}
+}
+
+func (r *idempotencyRepository) Claim(ctx context.Context, actor, key string, requestHash []byte, expiresAt time.Time, lockTimeout time.Duration) (db.IdempotencyKey, bool, error) {
+	// The key can be released between the failed claim and the read, so
+	// try once more before giving up
+	for attempt := 0; attempt < 2; attempt++ {
+		record, err := r.queries.ClaimIdempotencyKey(ctx, db.ClaimIdempotencyKeyParams{
+			Actor:       actor,
+			Key:         key,
+			RequestHash: requestHash,
+			ExpiresAt:   pgtype.Timestamptz{Time: expiresAt, Valid: true},
+			// Added to the database clock, like the NOW() it is compared with
+			LockTimeout: pgtype.Interval{Microseconds: lockTimeout.Microseconds(), Valid: true},
+		})
+		if err == nil {
+			return record, true, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return db.IdempotencyKey{}, false, translateError(err)
+		}
+
+		record, err = r.queries.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+			Actor: actor,
+			Key:   key,
+		})
+		if err == nil {
+			return record, false, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return db.IdempotencyKey{}, false, translateError(err)
+		}
+	}
+	return db.IdempotencyKey{}, false, ErrConflict
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, actor, key string, status int32, headers, body []byte) error {
+	err := r.queries.CompleteIdempotencyKey(ctx, db.CompleteIdempotencyKeyParams{
+		Actor:           actor,
+		Key:             key,
+		StatusCode:      pgtype.Int4{Int32: status, Valid: true},
+		ResponseHeaders: headers,
+		ResponseBody:    body,
+	})
+	return translateError(err)
+}
+
+func (r *idempotencyRepository) Release(ctx context.Context, actor, key string) error {
+	err := r.queries.DeleteIdempotencyKey(ctx, db.DeleteIdempotencyKeyParams{
+		Actor: actor,
+		Key:   key,
+	})
+	return translateError(err)
+}
+
+func (r *idempotencyRepository) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	count, err := r.queries.PurgeExpiredIdempotencyKeys(ctx, pgtype.Timestamptz{
+		Time:  before,
+		Valid: true,
+	})
+	return count, translateError(err)
+}
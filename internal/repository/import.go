@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/rohanparmar/go-user-api/internal/audit"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+)
+
+// ImportSource streams the rows of an import, like pgx.CopyFromSource.
+// Rows must already be valid.
+type ImportSource interface {
+	Next() bool
+	Row() models.ImportRow
+	Err() error
+}
+
+// ImportResult describes a staged import. Duplicates holds the line numbers of
+// duplicate rows (empty with models.ImportDuplicatesAllow). Active is the
+// number of active users the tenant had, counted in the import transaction.
+type ImportResult struct {
+	Staged     int64
+	Duplicates []int
+	Active     int64
+	Inserted   int64
+}
+
+// The import statements use a temporary table, which sqlc cannot see, so they
+// live here rather than in db/sqlc/queries.
+
+const createImportTable = `
+CREATE TEMPORARY TABLE user_import (
+    line INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    dob DATE NOT NULL
+) ON COMMIT DROP`
+
+// findImportDuplicates matches rows against the tenant's active users, using
+// idx_users_tenant_lower_name_dob, and against earlier rows of the file
+const findImportDuplicates = `
+SELECT line
+FROM (
+    SELECT i.line,
+           row_number() OVER (PARTITION BY lower(i.name), i.dob ORDER BY i.line) > 1 AS repeated,
+           EXISTS (
+               SELECT 1 FROM users u
+               WHERE u.tenant_id = $1 AND u.deleted_at IS NULL
+                 AND lower(u.name) = lower(i.name) AND u.dob = i.dob
+           ) AS stored
+    FROM user_import i
+) d
+WHERE repeated OR stored
+ORDER BY line`
+
+// insertImportedUsers inserts the staged rows and their audit rows in one
+// statement. The after snapshot mirrors models.UserSnapshot.
+const insertImportedUsers = `
+WITH inserted AS (
+    INSERT INTO users (tenant_id, name, dob)
+    SELECT $1, name, dob FROM user_import
+    WHERE line <> ALL($2::int[])
+    ORDER BY line
+    RETURNING id, name, dob, version
+)
+INSERT INTO user_audit (tenant_id, user_id, operation, actor, request_id, after)
+SELECT $1, id, $3, $4, $5,
+       jsonb_build_object('id', id, 'name', name, 'dob', to_char(dob, 'YYYY-MM-DD'), 'version', version)
+FROM inserted`
+
+// Import streams src into a temporary table with COPY, looks for duplicates
+// and, if insert approves the staged result, inserts the rows in bulk.
+// Duplicates are left out when opts.Duplicates is models.ImportDuplicatesSkip.
+// Nothing is written unless insert returns true.
+func (r *userRepository) Import(ctx context.Context, src ImportSource, opts models.ImportOptions, insert func(ImportResult) bool) (ImportResult, error) {
+	var result ImportResult
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return result, translateError(err)
+	}
+	defer tx.Rollback(ctx) // no-op once committed
+
+	if _, err := tx.Exec(ctx, createImportTable); err != nil {
+		return result, translateError(err)
+	}
+
+	result.Staged, err = tx.CopyFrom(ctx, pgx.Identifier{"user_import"}, []string{"line", "name", "dob"},
+		pgx.CopyFromFunc(func() ([]any, error) {
+			if !src.Next() {
+				return nil, src.Err()
+			}
+			row := src.Row()
+			return []any{int32(row.Line), row.Name, parsePGDate(row.DOB)}, nil
+		}),
+	)
+	if err != nil {
+		return result, translateError(err)
+	}
+
+	// Temporary tables are never analyzed automatically
+	if _, err := tx.Exec(ctx, "ANALYZE user_import"); err != nil {
+		return result, translateError(err)
+	}
+
+	if opts.Duplicates != models.ImportDuplicatesAllow {
+		rows, err := tx.Query(ctx, findImportDuplicates, tenant.ID(ctx))
+		if err != nil {
+			return result, translateError(err)
+		}
+		result.Duplicates, err = pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil {
+			return result, translateError(err)
+		}
+	}
+
+	// Counted on the transaction's connection, so that approving the import
+	// does not need a second one from the pool
+	result.Active, err = r.queries.WithTx(tx).CountUsers(ctx, db.CountUsersParams{TenantID: tenant.ID(ctx)})
+	if err != nil {
+		return result, translateError(err)
+	}
+
+	if !insert(result) {
+		return result, nil
+	}
+
+	skip := []int32{}
+	if opts.Duplicates == models.ImportDuplicatesSkip {
+		for _, line := range result.Duplicates {
+			skip = append(skip, int32(line))
+		}
+	}
+	tag, err := tx.Exec(ctx, insertImportedUsers, tenant.ID(ctx), skip,
+		audit.OperationCreate, audit.Actor(ctx), audit.RequestID(ctx),
+	)
+	if err != nil {
+		return result, translateError(err)
+	}
+	result.Inserted = tag.RowsAffected()
+
+	return result, translateError(tx.Commit(ctx))
+}
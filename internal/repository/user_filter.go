@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+)
+
+// UserFilter narrows the users returned by List and counted by Count.
+// Zero values mean "no restriction".
+type UserFilter struct {
+	NameContains   string
+	NamePrefix     string
+	DOBFrom        *time.Time // inclusive
+	DOBTo          *time.Time // inclusive
+	CreatedAfter   *time.Time // exclusive
+	IncludeDeleted bool
+
+	// AfterID restricts results to id > AfterID (keyset pagination).
+	// It is only meaningful with the default id ordering.
+	AfterID int32
+}
+
+// SortField orders List results by a whitelisted field
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// sortableColumns whitelists the fields clients may sort by. Column names are
+// never taken from user input, so ORDER BY cannot be used for SQL injection.
+var sortableColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"dob":        "dob",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// IsSortable reports whether field can be used in a SortField
+func IsSortable(field string) bool {
+	_, ok := sortableColumns[field]
+	return ok
+}
+
+// userColumns matches the column order scanned by scanUser and sqlc's db.User
+const userColumns = "id, name, dob, created_at, updated_at, version, deleted_at, tenant_id"
+
+// Filtered or sorted List and Count queries are built here rather than in
+// sqlc because the WHERE and ORDER BY clauses depend on which filters are set.
+// Every value is passed as a bind parameter. Unfiltered lists, the common
+// case, use the sqlc queries.
+
+// narrowed reports whether f restricts results beyond IncludeDeleted and
+// AfterID, which the sqlc queries cover
+func (f UserFilter) narrowed() bool {
+	return f.NameContains != "" || f.NamePrefix != "" ||
+		f.DOBFrom != nil || f.DOBTo != nil || f.CreatedAfter != nil
+}
+
+// userQuery accumulates WHERE conditions and their bind parameters
+type userQuery struct {
+	conditions []string
+	args       []any
+}
+
+// where adds a condition; %d in cond is replaced by the parameter number
+func (q *userQuery) where(cond string, arg any) {
+	q.args = append(q.args, arg)
+	q.conditions = append(q.conditions, fmt.Sprintf(cond, len(q.args)))
+}
+
+func (q *userQuery) bind(arg any) string {
+	q.args = append(q.args, arg)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+func (q *userQuery) whereClause() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(q.conditions, " AND ")
+}
+
+// newUserQuery translates a filter into WHERE conditions, restricted to the
+// tenant of ctx
+func newUserQuery(ctx context.Context, f UserFilter) *userQuery {
+	q := &userQuery{}
+	q.where("tenant_id = $%d", tenant.ID(ctx))
+	if !f.IncludeDeleted {
+		q.conditions = append(q.conditions, "deleted_at IS NULL")
+	}
+	if f.NameContains != "" {
+		q.where(`name ILIKE '%%' || $%d || '%%'`, escapeLike(f.NameContains))
+	}
+	if f.NamePrefix != "" {
+		q.where(`name ILIKE $%d || '%%'`, escapeLike(f.NamePrefix))
+	}
+	if f.DOBFrom != nil {
+		q.where("dob >= $%d", pgtype.Date{Time: *f.DOBFrom, Valid: true})
+	}
+	if f.DOBTo != nil {
+		q.where("dob <= $%d", pgtype.Date{Time: *f.DOBTo, Valid: true})
+	}
+	if f.CreatedAfter != nil {
+		q.where("created_at > $%d", pgtype.Timestamptz{Time: *f.CreatedAfter, Valid: true})
+	}
+	if f.AfterID > 0 {
+		q.where("id > $%d", f.AfterID)
+	}
+	return q
+}
+
+// orderBy builds the ORDER BY clause, always ending with id as a tie-breaker
+// so pages are stable
+func orderBy(sort []SortField) (string, error) {
+	terms := make([]string, 0, len(sort)+1)
+	hasID := false
+	for _, s := range sort {
+		column, ok := sortableColumns[s.Field]
+		if !ok {
+			return "", fmt.Errorf("%w: cannot sort by %q", ErrInvalid, s.Field)
+		}
+		if column == "id" {
+			hasID = true
+		}
+		if s.Desc {
+			column += " DESC"
+		}
+		terms = append(terms, column)
+	}
+	if !hasID {
+		terms = append(terms, "id")
+	}
+	return " ORDER BY " + strings.Join(terms, ", "), nil
+}
+
+// escapeLike escapes LIKE wildcards so user input is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func (r *userRepository) List(ctx context.Context, filter UserFilter, sort []SortField, limit, offset int32) ([]db.User, error) {
+	if !filter.narrowed() && len(sort) == 0 {
+		return r.listByID(ctx, filter, limit, offset)
+	}
+
+	q := newUserQuery(ctx, filter)
+	order, err := orderBy(sort)
+	if err != nil {
+		return nil, err
+	}
+
+	sql := "SELECT " + userColumns + " FROM users" + q.whereClause() + order +
+		" LIMIT " + q.bind(limit) + " OFFSET " + q.bind(offset)
+
+	rows, err := r.pool.Query(ctx, sql, q.args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	users, err := pgx.CollectRows(rows, scanUser)
+	return users, translateError(err)
+}
+
+// listByID lists users in id order with the sqlc queries, by keyset when
+// filter.AfterID is set
+func (r *userRepository) listByID(ctx context.Context, filter UserFilter, limit, offset int32) ([]db.User, error) {
+	var users []db.User
+	var err error
+	if filter.AfterID > 0 {
+		users, err = r.queries.ListUsersAfter(ctx, db.ListUsersAfterParams{
+			TenantID:       tenant.ID(ctx),
+			AfterID:        filter.AfterID,
+			IncludeDeleted: filter.IncludeDeleted,
+			Limit:          limit,
+		})
+	} else {
+		users, err = r.queries.ListUsers(ctx, db.ListUsersParams{
+			TenantID:       tenant.ID(ctx),
+			IncludeDeleted: filter.IncludeDeleted,
+			Limit:          limit,
+			Offset:         offset,
+		})
+	}
+	return users, translateError(err)
+}
+
+func (r *userRepository) Count(ctx context.Context, filter UserFilter) (int64, error) {
+	if !filter.narrowed() && filter.AfterID == 0 {
+		count, err := r.queries.CountUsers(ctx, db.CountUsersParams{
+			TenantID:       tenant.ID(ctx),
+			IncludeDeleted: filter.IncludeDeleted,
+		})
+		return count, translateError(err)
+	}
+
+	q := newUserQuery(ctx, filter)
+
+	var count int64
+	err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM users"+q.whereClause(), q.args...).Scan(&count)
+	return count, translateError(err)
+}
+
+// scanUser scans a row selected with userColumns
+func scanUser(row pgx.CollectableRow) (db.User, error) {
+	var i db.User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Dob,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.DeletedAt,
+		&i.TenantID,
+	)
+	return i, err
+}
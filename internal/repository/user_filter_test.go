@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUserQuery(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "acme")
+	q := newUserQuery(ctx, UserFilter{NameContains: "50%_off", NamePrefix: "Al", AfterID: 10})
+
+	assert.Equal(t,
+		` WHERE tenant_id = $1 AND deleted_at IS NULL AND name ILIKE '%' || $2 || '%' AND name ILIKE $3 || '%' AND id > $4`,
+		q.whereClause(),
+	)
+	assert.Equal(t, []any{"acme", `50\%\_off`, "Al", int32(10)}, q.args)
+
+	q = newUserQuery(context.Background(), UserFilter{IncludeDeleted: true})
+	assert.Equal(t, " WHERE tenant_id = $1", q.whereClause())
+	assert.Equal(t, []any{tenant.Default}, q.args)
+}
+
+func TestUserFilterNarrowed(t *testing.T) {
+	// Only these filters are served by the sqlc list queries
+	assert.False(t, UserFilter{}.narrowed())
+	assert.False(t, UserFilter{IncludeDeleted: true, AfterID: 10}.narrowed())
+
+	dob := time.Date(1990, 5, 10, 0, 0, 0, 0, time.UTC)
+	assert.True(t, UserFilter{NamePrefix: "Al"}.narrowed())
+	assert.True(t, UserFilter{DOBTo: &dob}.narrowed())
+}
+
+func TestOrderBy(t *testing.T) {
+	order, err := orderBy([]SortField{{Field: "name"}, {Field: "dob", Desc: true}})
+	assert.NoError(t, err)
+	assert.Equal(t, " ORDER BY name, dob DESC, id", order)
+
+	order, err = orderBy([]SortField{{Field: "id", Desc: true}})
+	assert.NoError(t, err)
+	assert.Equal(t, " ORDER BY id DESC", order)
+
+	_, err = orderBy([]SortField{{Field: "name; DROP TABLE users"}})
+	assert.ErrorIs(t, err, ErrInvalid)
+}
@@ -7,15 +7,34 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/rohanparmar/go-user-api/internal/models"
 )
 
+// UserRepository reads exclude soft-deleted users unless UserFilter.IncludeDeleted is set;
+// Delete only marks a user as deleted and Purge removes such users for good.
+// Methods that modify a user accept ifMatch, the row versions
+// the caller expects (from If-Match). A nil slice skips the check; a non-nil
+// slice that does not contain the stored version makes the call return ErrNotFound.
 type UserRepository interface {
 	Create(ctx context.Context, name string, dob string) (db.User, error)
 	GetByID(ctx context.Context, id int32) (db.User, error)
-	List(ctx context.Context, limit, offset int32) ([]db.User, error)
-	Count(ctx context.Context) (int64, error)
-	Update(ctx context.Context, id int32, name string, dob string) (db.User, error)
-	Delete(ctx context.Context, id int32) error
+	List(ctx context.Context, filter UserFilter, sort []SortField, limit, offset int32) ([]db.User, error)
+	Count(ctx context.Context, filter UserFilter) (int64, error)
+	Search(ctx context.Context, query string, limit int32) ([]db.SearchUsersRow, error)
+	Export(ctx context.Context, filter UserFilter, sort []SortField, fn func(db.User) error) error
+	Update(ctx context.Context, id int32, name string, dob string, ifMatch []int32) (db.User, error)
+	Patch(ctx context.Context, id int32, patch models.UserPatch) (db.User, error)
+	Delete(ctx context.Context, id int32, ifMatch []int32) error
+	Restore(ctx context.Context, id int32) (db.User, error)
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	Batch(ctx context.Context, ops []models.BatchOperation, atomic bool) ([]BatchResult, error)
+	Import(ctx context.Context, src ImportSource, opts models.ImportOptions, insert func(ImportResult) bool) (ImportResult, error)
+
+	// Audit trail, newest first
+	History(ctx context.Context, userID int32, limit, offset int32) ([]db.UserAudit, error)
+	CountHistory(ctx context.Context, userID int32) (int64, error)
+	GetAsOf(ctx context.Context, userID int32, asOf time.Time) (db.UserAudit, error)
 }
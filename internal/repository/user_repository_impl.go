@@ -1,7 +1,10 @@
 /*
 Package repository implements the data access layer.
 The userRepository struct uses the generated SQLC code (`db.Queries`) to execute SQL queries
-against the PostgreSQL database. It handles type conversions and data retrieval.
+against the PostgreSQL database. It handles type conversions and data retrieval,
+and translates driver errors into the sentinel errors defined in errors.go.
+Every write runs in a transaction together with its user_audit row (see audit.go).
+Every query is scoped to the tenant stored in the context (see package tenant).
 */
 package repository
 
@@ -10,51 +13,183 @@ import (
 	"time"
 
 	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/rohanparmar/go-user-api/internal/audit"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type userRepository struct {
+	pool    *pgxpool.Pool
 	queries *db.Queries
 }
 
-func NewUserRepository(queries *db.Queries) UserRepository {
+func NewUserRepository(pool *pgxpool.Pool) UserRepository {
 	return &userRepository{
-		queries: queries,
+		pool:    pool,
+		queries: db.New(pool),
 	}
 }
 
+// withTx runs fn in a transaction, committing only if fn succeeds
+func (r *userRepository) withTx(ctx context.Context, fn func(q *db.Queries) error) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+	defer tx.Rollback(ctx) // no-op once committed
+
+	if err := fn(r.queries.WithTx(tx)); err != nil {
+		return translateError(err)
+	}
+	return translateError(tx.Commit(ctx))
+}
+
+// lockActiveUser locks a user row for the rest of the transaction.
+// Soft-deleted users are reported as not found.
+func lockActiveUser(ctx context.Context, q *db.Queries, id int32) (db.User, error) {
+	user, err := q.GetUserByIDForUpdate(ctx, db.GetUserByIDForUpdateParams{
+		TenantID: tenant.ID(ctx),
+		ID:       id,
+	})
+	if err != nil {
+		return db.User{}, err
+	}
+	if user.DeletedAt.Valid {
+		return db.User{}, ErrNotFound
+	}
+	return user, nil
+}
+
 func (r *userRepository) Create(ctx context.Context, name string, dob string) (db.User, error) {
-	return r.queries.CreateUser(ctx, db.CreateUserParams{
-		Name: name,
-		Dob:  parsePGDate(dob),
+	var user db.User
+	err := r.withTx(ctx, func(q *db.Queries) error {
+		var err error
+		user, err = q.CreateUser(ctx, db.CreateUserParams{
+			TenantID: tenant.ID(ctx),
+			Name:     name,
+			Dob:      parsePGDate(dob),
+		})
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, audit.OperationCreate, nil, &user)
 	})
+	return user, err
 }
 
 func (r *userRepository) GetByID(ctx context.Context, id int32) (db.User, error) {
-	return r.queries.GetUserByID(ctx, id)
+	user, err := r.queries.GetUserByID(ctx, db.GetUserByIDParams{
+		TenantID: tenant.ID(ctx),
+		ID:       id,
+	})
+	return user, translateError(err)
 }
 
-func (r *userRepository) List(ctx context.Context, limit, offset int32) ([]db.User, error) {
-	return r.queries.ListUsers(ctx, db.ListUsersParams{
-		Limit:  limit,
-		Offset: offset,
+// Search ranks active users by trigram similarity and full-text match on name
+func (r *userRepository) Search(ctx context.Context, query string, limit int32) ([]db.SearchUsersRow, error) {
+	users, err := r.queries.SearchUsers(ctx, db.SearchUsersParams{
+		Query:    query,
+		TenantID: tenant.ID(ctx),
+		Limit:    limit,
 	})
+	return users, translateError(err)
 }
 
-func (r *userRepository) Count(ctx context.Context) (int64, error) {
-	return r.queries.CountUsers(ctx)
+func (r *userRepository) Update(ctx context.Context, id int32, name string, dob string, ifMatch []int32) (db.User, error) {
+	var user db.User
+	err := r.withTx(ctx, func(q *db.Queries) error {
+		before, err := lockActiveUser(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		user, err = q.UpdateUser(ctx, db.UpdateUserParams{
+			TenantID:         tenant.ID(ctx),
+			ID:               id,
+			Name:             name,
+			Dob:              parsePGDate(dob),
+			ExpectedVersions: ifMatch,
+		})
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, audit.OperationUpdate, &before, &user)
+	})
+	return user, err
 }
 
-func (r *userRepository) Update(ctx context.Context, id int32, name string, dob string) (db.User, error) {
-	return r.queries.UpdateUser(ctx, db.UpdateUserParams{
-		ID:   id,
-		Name: name,
-		Dob:  parsePGDate(dob),
+func (r *userRepository) Patch(ctx context.Context, id int32, patch models.UserPatch) (db.User, error) {
+	var user db.User
+	err := r.withTx(ctx, func(q *db.Queries) error {
+		before, err := lockActiveUser(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		user, err = q.PatchUser(ctx, db.PatchUserParams{
+			TenantID:         tenant.ID(ctx),
+			ID:               id,
+			Name:             optionalText(patch.Name),
+			Dob:              optionalDate(patch.DOB),
+			ExpectedName:     optionalText(patch.TestName),
+			ExpectedDob:      optionalDate(patch.TestDOB),
+			ExpectedVersions: patch.IfMatch,
+		})
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, audit.OperationPatch, &before, &user)
 	})
+	return user, err
 }
 
-func (r *userRepository) Delete(ctx context.Context, id int32) error {
-	return r.queries.DeleteUser(ctx, id)
+func (r *userRepository) Delete(ctx context.Context, id int32, ifMatch []int32) error {
+	return r.withTx(ctx, func(q *db.Queries) error {
+		before, err := lockActiveUser(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		after, err := q.DeleteUser(ctx, db.DeleteUserParams{
+			TenantID:         tenant.ID(ctx),
+			ID:               id,
+			ExpectedVersions: ifMatch,
+		})
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, audit.OperationDelete, &before, &after)
+	})
+}
+
+func (r *userRepository) Restore(ctx context.Context, id int32) (db.User, error) {
+	var user db.User
+	err := r.withTx(ctx, func(q *db.Queries) error {
+		key := db.GetUserByIDForUpdateParams{TenantID: tenant.ID(ctx), ID: id}
+		before, err := q.GetUserByIDForUpdate(ctx, key)
+		if err != nil {
+			return err
+		}
+		user, err = q.RestoreUser(ctx, db.RestoreUserParams(key))
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, q, audit.OperationRestore, &before, &user)
+	})
+	return user, err
+}
+
+// Purge hard-deletes the tenant's users soft-deleted before deletedBefore, or
+// those of every tenant when ctx acts for tenant.All
+func (r *userRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	id := tenant.ID(ctx)
+	count, err := r.queries.PurgeDeletedUsers(ctx, db.PurgeDeletedUsersParams{
+		TenantID: pgtype.Text{String: id, Valid: id != tenant.All},
+		DeletedBefore: pgtype.Timestamptz{
+			Time:  deletedBefore,
+			Valid: true,
+		},
+	})
+	return count, translateError(err)
 }
 
 // parsePGDate converts "YYYY-MM-DD" string to pgtype.Date
@@ -65,3 +200,19 @@ func parsePGDate(d string) pgtype.Date {
 		Valid: true,
 	}
 }
+
+// optionalText converts an optional string to pgtype.Text (NULL when nil)
+func optionalText(s *string) pgtype.Text {
+	if s == nil {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: *s, Valid: true}
+}
+
+// optionalDate converts an optional "YYYY-MM-DD" string to pgtype.Date (NULL when nil)
+func optionalDate(d *string) pgtype.Date {
+	if d == nil {
+		return pgtype.Date{}
+	}
+	return parsePGDate(*d)
+}
@@ -6,14 +6,34 @@ package routes
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/rohanparmar/go-user-api/internal/auth"
 	"github.com/rohanparmar/go-user-api/internal/handler"
 )
 
-func SetupRoutes(app *fiber.App, userHandler *handler.UserHandler) {
-	app.Post("/users", userHandler.CreateUser)
-	app.Get("/users", userHandler.ListUsers)
-	app.Get("/users/:id", userHandler.GetUser)
-	app.Put("/users/:id", userHandler.UpdateUser)
-	app.Delete("/users/:id", userHandler.DeleteUser)
-}
+// SetupRoutes registers the probes, /metrics and the user endpoints. idempotent is applied to the POST
+// endpoints that are not naturally idempotent (see middleware.Idempotency).
+// POST /users/import is left out as its body is streamed rather than buffered.
+// The probes and /metrics are public; every user endpoint requires a scope,
+// checked by the handler returned by require before anything else runs.
+func SetupRoutes(app *fiber.App, userHandler *handler.UserHandler, healthHandler *handler.HealthHandler, metricsHandler fiber.Handler, idempotent fiber.Handler, require func(auth.Scope) fiber.Handler) {
+	read := require(auth.ScopeUsersRead)
+	write := require(auth.ScopeUsersWrite)
+	admin := require(auth.ScopeUsersAdmin)
+
+	app.Get("/healthz", healthHandler.Liveness)
+	app.Get("/readyz", healthHandler.Readiness)
+	app.Get("/metrics", metricsHandler)
 
+	app.Post("/users", write, idempotent, userHandler.CreateUser)
+	app.Post("/users\\:batch", write, idempotent, userHandler.BatchUsers) // escaped so ":batch" is not a parameter
+	app.Post("/users/import", admin, userHandler.ImportUsers)
+	app.Get("/users", read, userHandler.ListUsers)
+	app.Get("/users/search", read, userHandler.SearchUsers) // before /users/:id so "search" is not read as an ID
+	app.Get("/users/export", read, userHandler.ExportUsers)
+	app.Get("/users/:id", read, userHandler.GetUser)
+	app.Get("/users/:id/history", read, userHandler.GetUserHistory)
+	app.Put("/users/:id", write, userHandler.UpdateUser)
+	app.Patch("/users/:id", write, userHandler.PatchUser)
+	app.Delete("/users/:id", write, userHandler.DeleteUser)
+	app.Post("/users/:id/restore", admin, idempotent, userHandler.RestoreUser)
+}
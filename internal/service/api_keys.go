@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/rohanparmar/go-user-api/internal/auth"
+	"github.com/rohanparmar/go-user-api/internal/repository"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+)
+
+// API keys look like "uak_<prefix>_<secret>". The prefix is public and used to
+// find the key; only a SHA-256 hash of the whole key is stored. A hash is
+// enough because the secret is random, unlike a password.
+const (
+	apiKeyTag          = "uak_"
+	apiKeyPrefixBytes  = 6
+	apiKeySecretBytes  = 32
+	apiKeyPrefixLength = 2 * apiKeyPrefixBytes
+)
+
+// ErrInvalidAPIKey is returned for keys that are malformed, unknown, revoked
+// or expired; the caller is not told which
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// APIKeyService mints, rotates and checks API keys
+type APIKeyService interface {
+	// Mint creates a key; a ttl of zero means it does not expire. The key can
+	// only act for tenantID, which is required; tenant.All lets it act for
+	// any tenant.
+	Mint(ctx context.Context, name, tenantID string, scopes []auth.Scope, ttl time.Duration) (MintedAPIKey, error)
+	// Rotate mints a replacement with the same name, tenant, scopes and lifetime,
+	// and lets the old key expire after overlap so clients can switch over
+	Rotate(ctx context.Context, id int32, overlap time.Duration) (MintedAPIKey, error)
+	Revoke(ctx context.Context, id int32) error
+	List(ctx context.Context) ([]db.ApiKey, error)
+	Authenticate(ctx context.Context, key string) (auth.Principal, error)
+}
+
+// MintedAPIKey is a new key. Key is shown once and cannot be recovered.
+type MintedAPIKey struct {
+	Key    string
+	Record db.ApiKey
+}
+
+type apiKeyService struct {
+	repo repository.APIKeyRepository
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository) APIKeyService {
+	return &apiKeyService{repo: repo}
+}
+
+func (s *apiKeyService) Mint(ctx context.Context, name, tenantID string, scopes []auth.Scope, ttl time.Duration) (MintedAPIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return MintedAPIKey{}, NewValidationError("name", "name is required")
+	}
+	if tenantID == "" {
+		return MintedAPIKey{}, NewValidationError("tenant", "tenant is required")
+	}
+	if tenantID != tenant.All && !tenant.Valid(tenantID) {
+		return MintedAPIKey{}, NewValidationError("tenant", "invalid tenant ID")
+	}
+	if len(scopes) == 0 {
+		return MintedAPIKey{}, NewValidationError("scopes", "at least one scope is required")
+	}
+	if ttl < 0 {
+		return MintedAPIKey{}, NewValidationError("ttl", "ttl cannot be negative")
+	}
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+	return s.mint(ctx, name, tenantID, scopes, expiresAt)
+}
+
+func (s *apiKeyService) mint(ctx context.Context, name, tenantID string, scopes []auth.Scope, expiresAt time.Time) (MintedAPIKey, error) {
+	key, prefix, err := generateAPIKey()
+	if err != nil {
+		return MintedAPIKey{}, err
+	}
+
+	scopeNames := make([]string, len(scopes))
+	for i, scope := range scopes {
+		scopeNames[i] = string(scope)
+	}
+
+	record, err := s.repo.Create(ctx, name, tenantID, prefix, hashAPIKey(key), scopeNames, expiresAt)
+	if err != nil {
+		return MintedAPIKey{}, translateRepoError(err)
+	}
+	return MintedAPIKey{Key: key, Record: record}, nil
+}
+
+func (s *apiKeyService) Rotate(ctx context.Context, id int32, overlap time.Duration) (MintedAPIKey, error) {
+	if overlap < 0 {
+		return MintedAPIKey{}, NewValidationError("overlap", "overlap cannot be negative")
+	}
+
+	old, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return MintedAPIKey{}, translateRepoError(err)
+	}
+	if !apiKeyActive(old, time.Now()) {
+		return MintedAPIKey{}, NewValidationError("id", "only active keys can be rotated")
+	}
+
+	// The replacement gets the lifetime the old key was minted with
+	var expiresAt time.Time
+	if old.ExpiresAt.Valid {
+		expiresAt = time.Now().Add(old.ExpiresAt.Time.Sub(old.CreatedAt.Time))
+	}
+
+	scopes := make([]auth.Scope, len(old.Scopes))
+	for i, scope := range old.Scopes {
+		scopes[i] = auth.Scope(scope)
+	}
+
+	// Mint first: if expiring the old key fails, both keys stay valid and
+	// the rotation can be finished with a revoke
+	minted, err := s.mint(ctx, old.Name, old.TenantID, scopes, expiresAt)
+	if err != nil {
+		return MintedAPIKey{}, err
+	}
+	if err := s.repo.Expire(ctx, id, time.Now().Add(overlap)); err != nil {
+		return MintedAPIKey{}, translateRepoError(err)
+	}
+	return minted, nil
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, id int32) error {
+	return translateRepoError(s.repo.Revoke(ctx, id))
+}
+
+func (s *apiKeyService) List(ctx context.Context) ([]db.ApiKey, error) {
+	keys, err := s.repo.List(ctx)
+	return keys, translateRepoError(err)
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, key string) (auth.Principal, error) {
+	prefix, ok := parseAPIKey(key)
+	if !ok {
+		return auth.Principal{}, ErrInvalidAPIKey
+	}
+
+	record, err := s.repo.GetByPrefix(ctx, prefix)
+	if errors.Is(err, repository.ErrNotFound) {
+		return auth.Principal{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return auth.Principal{}, translateRepoError(err)
+	}
+
+	if subtle.ConstantTimeCompare(record.KeyHash, hashAPIKey(key)) != 1 || !apiKeyActive(record, time.Now()) {
+		return auth.Principal{}, ErrInvalidAPIKey
+	}
+
+	scopes := make([]auth.Scope, len(record.Scopes))
+	for i, scope := range record.Scopes {
+		scopes[i] = auth.Scope(scope)
+	}
+	// Each scope is also a role of the Policy (see auth.DefaultRoles)
+	return auth.Principal{
+		Subject: "apikey:" + record.Name,
+		Scopes:  scopes,
+		Roles:   record.Scopes,
+		Tenant:  record.TenantID,
+	}, nil
+}
+
+// apiKeyActive reports whether a key is neither revoked nor expired at now
+func apiKeyActive(key db.ApiKey, now time.Time) bool {
+	if key.RevokedAt.Valid {
+		return false
+	}
+	return !key.ExpiresAt.Valid || now.Before(key.ExpiresAt.Time)
+}
+
+// generateAPIKey returns a new random key and its prefix
+func generateAPIKey() (key, prefix string, err error) {
+	buf := make([]byte, apiKeyPrefixBytes+apiKeySecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	prefix = hex.EncodeToString(buf[:apiKeyPrefixBytes])
+	secret := base64.RawURLEncoding.EncodeToString(buf[apiKeyPrefixBytes:])
+	return apiKeyTag + prefix + "_" + secret, prefix, nil
+}
+
+// parseAPIKey returns the prefix of key, if it is well formed
+func parseAPIKey(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, apiKeyTag)
+	if !ok || len(rest) <= apiKeyPrefixLength+1 || rest[apiKeyPrefixLength] != '_' {
+		return "", false
+	}
+	prefix := rest[:apiKeyPrefixLength]
+	if _, err := hex.DecodeString(prefix); err != nil {
+		return "", false
+	}
+	return prefix, true
+}
+
+func hashAPIKey(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
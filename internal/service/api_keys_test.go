@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/rohanparmar/go-user-api/internal/auth"
+	"github.com/rohanparmar/go-user-api/internal/repository"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryAPIKeyRepo keeps keys in a slice, indexed by ID - 1
+type memoryAPIKeyRepo struct {
+	repository.APIKeyRepository
+	keys []db.ApiKey
+}
+
+func (m *memoryAPIKeyRepo) Create(ctx context.Context, name, tenantID, prefix string, keyHash []byte, scopes []string, expiresAt time.Time) (db.ApiKey, error) {
+	key := db.ApiKey{
+		ID:        int32(len(m.keys) + 1),
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   keyHash,
+		Scopes:    scopes,
+		CreatedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: !expiresAt.IsZero()},
+		TenantID:  tenantID,
+	}
+	m.keys = append(m.keys, key)
+	return key, nil
+}
+
+func (m *memoryAPIKeyRepo) Get(ctx context.Context, id int32) (db.ApiKey, error) {
+	if id < 1 || int(id) > len(m.keys) {
+		return db.ApiKey{}, repository.ErrNotFound
+	}
+	return m.keys[id-1], nil
+}
+
+func (m *memoryAPIKeyRepo) GetByPrefix(ctx context.Context, prefix string) (db.ApiKey, error) {
+	for _, key := range m.keys {
+		if key.Prefix == prefix {
+			return key, nil
+		}
+	}
+	return db.ApiKey{}, repository.ErrNotFound
+}
+
+func (m *memoryAPIKeyRepo) Revoke(ctx context.Context, id int32) error {
+	m.keys[id-1].RevokedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	return nil
+}
+
+func (m *memoryAPIKeyRepo) Expire(ctx context.Context, id int32, at time.Time) error {
+	m.keys[id-1].ExpiresAt = pgtype.Timestamptz{Time: at, Valid: true}
+	return nil
+}
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	repo := &memoryAPIKeyRepo{}
+	keys := NewAPIKeyService(repo)
+	ctx := context.Background()
+
+	minted, err := keys.Mint(ctx, "billing", tenant.All, []auth.Scope{auth.ScopeUsersWrite}, 0)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(minted.Record.KeyHash), minted.Key, "only the hash is stored")
+
+	principal, err := keys.Authenticate(ctx, minted.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, "apikey:billing", principal.Subject)
+	assert.True(t, principal.HasScope(auth.ScopeUsersRead))
+	assert.False(t, principal.HasScope(auth.ScopeUsersAdmin))
+	assert.Equal(t, tenant.All, principal.Tenant)
+
+	for _, key := range []string{"", "not-a-key", minted.Key + "x", "uak_zzzzzzzzzzzz_secret"} {
+		_, err := keys.Authenticate(ctx, key)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey, key)
+	}
+
+	assert.NoError(t, keys.Revoke(ctx, minted.Record.ID))
+	_, err = keys.Authenticate(ctx, minted.Key)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	_, err = keys.Mint(ctx, " ", tenant.Default, []auth.Scope{auth.ScopeUsersRead}, 0)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	// Acting for any tenant has to be asked for with tenant.All
+	_, err = keys.Mint(ctx, "billing", "", []auth.Scope{auth.ScopeUsersRead}, 0)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = keys.Mint(ctx, "billing", "acme corp", []auth.Scope{auth.ScopeUsersRead}, 0)
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestAPIKeyRotate(t *testing.T) {
+	repo := &memoryAPIKeyRepo{}
+	keys := NewAPIKeyService(repo)
+	ctx := context.Background()
+
+	old, err := keys.Mint(ctx, "billing", "acme", []auth.Scope{auth.ScopeUsersRead}, 30*24*time.Hour)
+	assert.NoError(t, err)
+
+	rotated, err := keys.Rotate(ctx, old.Record.ID, time.Hour)
+	assert.NoError(t, err)
+	assert.NotEqual(t, old.Key, rotated.Key)
+	assert.Equal(t, old.Record.Name, rotated.Record.Name)
+	assert.Equal(t, old.Record.Scopes, rotated.Record.Scopes)
+	assert.Equal(t, "acme", rotated.Record.TenantID)
+	assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), rotated.Record.ExpiresAt.Time, time.Minute)
+
+	// Both keys work during the overlap
+	_, err = keys.Authenticate(ctx, old.Key)
+	assert.NoError(t, err)
+	principal, err := keys.Authenticate(ctx, rotated.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", principal.Tenant)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), repo.keys[0].ExpiresAt.Time, time.Minute)
+
+	// Once the overlap is over, only the new key is accepted
+	repo.keys[0].ExpiresAt.Time = time.Now().Add(-time.Second)
+	_, err = keys.Authenticate(ctx, old.Key)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	_, err = keys.Rotate(ctx, old.Record.ID, time.Hour)
+	assert.ErrorIs(t, err, ErrValidation, "expired keys cannot be rotated")
+}
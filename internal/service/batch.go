@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/rohanparmar/go-user-api/internal/auth"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/repository"
+)
+
+// DefaultBatchLimit is the maximum number of operations in a batch unless
+// configured with WithBatchLimit
+const DefaultBatchLimit = 1000
+
+// BatchResult is the outcome of one batch operation; Err uses the service
+// error taxonomy and is nil when the operation was applied
+type BatchResult = repository.BatchResult
+
+// BatchUsers applies create, update and delete operations in one go.
+// Atomic batches are all-or-nothing: if any operation fails it reports its own
+// error and every other operation reports ErrBatchAborted. Otherwise failed
+// operations report their error and the others are committed. Creates beyond
+// the tenant's quota fail with a *QuotaError. Operations marked Invalid count
+// towards the batch limit and fail with their own error.
+// The returned error is only set when the batch as a whole could not be run.
+func (s *userService) BatchUsers(ctx context.Context, ops []models.BatchOperation, atomic bool) ([]BatchResult, error) {
+	if len(ops) == 0 {
+		return nil, NewValidationError("operations", "batch must contain at least one operation")
+	}
+	if len(ops) > s.batchLimit {
+		return nil, NewValidationError("operations", fmt.Sprintf("batch cannot contain more than %d operations", s.batchLimit))
+	}
+
+	remaining := int64(unlimited)
+	if slices.ContainsFunc(ops, func(op models.BatchOperation) bool { return op.Op == models.BatchOpCreate }) {
+		var err error
+		if remaining, err = s.quotaRemaining(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate up front so only valid operations reach the database
+	results := make([]BatchResult, len(ops))
+	valid := make([]models.BatchOperation, 0, len(ops))
+	indexes := make([]int, 0, len(ops))
+	seen := make(map[int32]bool)
+	for i, op := range ops {
+		if op.Invalid != nil {
+			results[i].Err = op.Invalid
+			continue
+		}
+		if err := s.authorizeBatchOperation(ctx, op); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if err := validateBatchOperation(op, seen); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if op.Op == models.BatchOpCreate && remaining != unlimited {
+			if remaining == 0 {
+				results[i].Err = s.quotaError(ctx)
+				continue
+			}
+			remaining--
+		}
+		valid = append(valid, op)
+		indexes = append(indexes, i)
+	}
+
+	if len(valid) > 0 && (!atomic || len(valid) == len(ops)) {
+		applied, err := s.repo.Batch(ctx, valid, atomic)
+		if err != nil {
+			return nil, translateRepoError(err)
+		}
+		for j, result := range applied {
+			i := indexes[j]
+			results[i] = result
+			if result.Err == nil {
+				continue
+			}
+			if errors.Is(result.Err, ErrNotFound) && ops[i].IfMatch != nil {
+				results[i].Err = s.explainNoMatch(ctx, ops[i].ID, ops[i].IfMatch, false)
+			} else {
+				results[i].Err = translateRepoError(result.Err)
+			}
+		}
+	}
+
+	if atomic && hasBatchFailure(results) {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i] = BatchResult{Err: ErrBatchAborted}
+			}
+		}
+		return results, nil
+	}
+
+	for i, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		switch ops[i].Op {
+		case models.BatchOpCreate:
+			s.metrics.UsersCreated.Inc()
+		case models.BatchOpUpdate:
+			s.metrics.UsersUpdated.Inc()
+		case models.BatchOpDelete:
+			s.metrics.UsersDeleted.Inc()
+		}
+	}
+	return results, nil
+}
+
+// authorizeBatchOperation checks the permission of the single operation op
+// would be, so a batch grants nothing the separate calls would not
+func (s *userService) authorizeBatchOperation(ctx context.Context, op models.BatchOperation) error {
+	switch op.Op {
+	case models.BatchOpCreate:
+		return s.authorize(ctx, auth.PermUserCreate, 0)
+	case models.BatchOpUpdate:
+		return s.authorize(ctx, auth.PermUserUpdate, op.ID)
+	case models.BatchOpDelete:
+		return s.authorize(ctx, auth.PermUserDelete, op.ID)
+	}
+	return nil
+}
+
+// validateBatchOperation applies the create/update business rules and rejects
+// a second operation on the same user, which would make the result depend on
+// execution order
+func validateBatchOperation(op models.BatchOperation, seen map[int32]bool) error {
+	switch op.Op {
+	case models.BatchOpCreate:
+		return validateUser(op.Name, op.DOB)
+	case models.BatchOpUpdate, models.BatchOpDelete:
+		if op.ID < 1 {
+			return NewValidationError("id", "id must be a positive integer")
+		}
+		if seen[op.ID] {
+			return NewValidationError("id", fmt.Sprintf("user %d appears more than once in the batch", op.ID))
+		}
+		seen[op.ID] = true
+		if op.Op == models.BatchOpUpdate {
+			return validateUser(op.Name, op.DOB)
+		}
+		return nil
+	default:
+		return NewValidationError("op", fmt.Sprintf("unknown batch operation %q", op.Op))
+	}
+}
+
+// hasBatchFailure reports whether any operation failed
+func hasBatchFailure(results []BatchResult) bool {
+	for _, result := range results {
+		if result.Err != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// batchRepo applies every operation except deletes of missing users
+type batchRepo struct {
+	repository.UserRepository
+	calls int
+	ops   []models.BatchOperation
+}
+
+func (m *batchRepo) Batch(ctx context.Context, ops []models.BatchOperation, atomic bool) ([]repository.BatchResult, error) {
+	m.calls++
+	m.ops = ops
+	results := make([]repository.BatchResult, len(ops))
+	for i, op := range ops {
+		if op.Op == models.BatchOpDelete && op.ID == 404 {
+			if atomic {
+				results = make([]repository.BatchResult, len(ops))
+				results[i].Err = repository.ErrNotFound
+				return results, nil
+			}
+			results[i].Err = repository.ErrNotFound
+			continue
+		}
+		results[i].User = db.User{ID: int32(i + 1), Name: op.Name}
+	}
+	return results, nil
+}
+
+func TestBatchUsersBestEffort(t *testing.T) {
+	repo := &batchRepo{}
+	userService := NewUserService(repo)
+
+	results, err := userService.BatchUsers(context.Background(), []models.BatchOperation{
+		{Op: models.BatchOpCreate, Name: "Alice", DOB: "1990-05-10"},
+		{Op: models.BatchOpCreate, Name: "Bob", DOB: "10/05/1990"},
+		{Op: models.BatchOpDelete, ID: 404},
+		{Op: models.BatchOpUpdate, ID: 7, Name: "Carol", DOB: "1985-01-01"},
+		{Op: models.BatchOpDelete, ID: 7},
+		{Op: models.BatchOpCreate, Name: "D", DOB: "1990-05-10", Invalid: NewValidationError("user.name", "too short")},
+	}, false)
+	assert.NoError(t, err)
+	assert.Len(t, results, 6)
+
+	// Invalid operations never reach the repository
+	assert.Len(t, repo.ops, 3)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "Alice", results[0].User.Name)
+	assert.ErrorIs(t, results[1].Err, ErrValidation)
+	assert.ErrorIs(t, results[2].Err, ErrNotFound)
+	assert.NoError(t, results[3].Err)
+	assert.ErrorIs(t, results[4].Err, ErrValidation)
+	assert.ErrorIs(t, results[5].Err, ErrValidation)
+}
+
+func TestBatchUsersAtomic(t *testing.T) {
+	repo := &batchRepo{}
+	userService := NewUserService(repo)
+
+	results, err := userService.BatchUsers(context.Background(), []models.BatchOperation{
+		{Op: models.BatchOpCreate, Name: "Alice", DOB: "1990-05-10"},
+		{Op: models.BatchOpDelete, ID: 404},
+	}, true)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, results[0].Err, ErrBatchAborted)
+	assert.Empty(t, results[0].User.Name)
+	assert.ErrorIs(t, results[1].Err, ErrNotFound)
+
+	// An invalid operation aborts the batch without touching the database
+	results, err = userService.BatchUsers(context.Background(), []models.BatchOperation{
+		{Op: models.BatchOpCreate, Name: "Alice", DOB: "1990-05-10"},
+		{Op: models.BatchOpCreate, Name: "", DOB: "1990-05-10"},
+	}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, repo.calls)
+	assert.ErrorIs(t, results[0].Err, ErrBatchAborted)
+	assert.ErrorIs(t, results[1].Err, ErrValidation)
+}
+
+func TestBatchUsersLimit(t *testing.T) {
+	userService := NewUserService(&batchRepo{}, WithBatchLimit(1))
+
+	_, err := userService.BatchUsers(context.Background(), []models.BatchOperation{
+		{Op: models.BatchOpDelete, ID: 1},
+		{Op: models.BatchOpDelete, ID: 2},
+	}, true)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	// Operations that failed request validation count towards the limit too
+	_, err = userService.BatchUsers(context.Background(), []models.BatchOperation{
+		{Op: models.BatchOpDelete, ID: 1},
+		{Op: models.BatchOpDelete, Invalid: NewValidationError("id", "required")},
+	}, false)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	_, err = userService.BatchUsers(context.Background(), nil, true)
+	assert.ErrorIs(t, err, ErrValidation)
+}
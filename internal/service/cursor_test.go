@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/repository"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorCodec(t *testing.T) {
+	codec := newCursorCodec([]byte("secret"))
+
+	cursor := codec.encode(cursorState{AfterID: 42, Filter: "abc"})
+	state, err := codec.decode(cursor)
+	assert.NoError(t, err)
+	assert.Equal(t, cursorState{AfterID: 42, Filter: "abc"}, state)
+
+	// A cursor signed with another key or edited by the client is rejected
+	_, err = newCursorCodec([]byte("other")).decode(cursor)
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = codec.decode("eyJhIjo5OTl9." + cursor[len(cursor)-43:])
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+// keysetRepo serves users with IDs 1..n for keyset pagination
+type keysetRepo struct {
+	repository.UserRepository
+	n int32
+}
+
+func (m *keysetRepo) List(ctx context.Context, filter repository.UserFilter, sort []repository.SortField, limit, offset int32) ([]db.User, error) {
+	var users []db.User
+	for id := filter.AfterID + 1; id <= m.n && int32(len(users)) < limit; id++ {
+		users = append(users, db.User{ID: id})
+	}
+	return users, nil
+}
+
+func TestListUsersByCursor(t *testing.T) {
+	userService := NewUserService(&keysetRepo{n: 5}, WithCursorSecret([]byte("secret")))
+
+	var ids []int32
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		response, err := userService.ListUsers(context.Background(), models.ListUsersQuery{Limit: 2, Cursor: &cursor})
+		assert.NoError(t, err)
+		assert.Nil(t, response.Total)
+		for _, user := range response.Data {
+			ids = append(ids, user.ID)
+		}
+		if response.NextCursor == "" {
+			break
+		}
+		cursor = response.NextCursor
+	}
+	assert.Equal(t, []int32{1, 2, 3, 4, 5}, ids)
+
+	// A cursor cannot be replayed against a different query
+	_, err := userService.ListUsers(context.Background(), models.ListUsersQuery{Limit: 2, Cursor: &cursor, IncludeDeleted: true})
+	assert.ErrorIs(t, err, ErrValidation)
+
+	// or in another tenant
+	_, err = userService.ListUsers(tenant.WithID(context.Background(), "acme"), models.ListUsersQuery{Limit: 2, Cursor: &cursor})
+	assert.ErrorIs(t, err, ErrValidation)
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rohanparmar/go-user-api/internal/auth"
+	"github.com/rohanparmar/go-user-api/internal/repository"
+)
+
+// Error taxonomy returned by UserService.
+// The handler layer maps each of these to an HTTP status code, so any error
+// not matching one of them is treated as an internal server error.
+var (
+	ErrNotFound    = repository.ErrNotFound
+	ErrConflict    = repository.ErrConflict
+	ErrUnavailable = repository.ErrUnavailable
+	ErrValidation  = errors.New("validation failed")
+
+	// ErrPreconditionFailed means the caller's If-Match version is stale
+	ErrPreconditionFailed = errors.New("precondition failed")
+
+	// ErrBatchAborted marks operations of an atomic batch that were rolled
+	// back because another operation failed
+	ErrBatchAborted = errors.New("batch aborted")
+
+	// ErrForbidden means the policy denied the operation; the error is a
+	// *PermissionError naming the missing permission
+	ErrForbidden = auth.ErrForbidden
+
+	// ErrQuotaExceeded means the tenant already has as many active users as
+	// its quota allows; the error is a *QuotaError
+	ErrQuotaExceeded = errors.New("user quota exceeded")
+)
+
+// PermissionError names the permission the caller is missing
+type PermissionError = auth.PermissionError
+
+// QuotaError reports the quota a tenant has reached. It matches
+// ErrQuotaExceeded with errors.Is.
+type QuotaError struct {
+	Tenant string
+	Limit  int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("tenant %s has reached its quota of %d active users", e.Tenant, e.Limit)
+}
+
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// ValidationError describes a business rule violation on a single field.
+// It matches ErrValidation with errors.Is.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// NewValidationError creates a ValidationError for the given field
+func NewValidationError(field, message string) error {
+	return &ValidationError{Field: field, Message: message}
+}
+
+// translateRepoError maps repository errors onto the service taxonomy
+func translateRepoError(err error) error {
+	if errors.Is(err, repository.ErrInvalid) {
+		return &ValidationError{Message: "user data violates a database constraint"}
+	}
+	return err
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/rohanparmar/go-user-api/internal/auth"
+	"github.com/rohanparmar/go-user-api/internal/models"
+)
+
+// UserExport streams the users selected by ExportUsers to fn, with their age.
+// An error returned by fn stops the export and is returned as is.
+type UserExport func(ctx context.Context, fn func(models.UserResponse) error) error
+
+// ExportUsers validates the filters and sort of query exactly like ListUsers
+// (pagination parameters are ignored) and returns the export to run. Keeping
+// the two steps apart lets callers report invalid queries before streaming.
+func (s *userService) ExportUsers(ctx context.Context, query models.ListUsersQuery) (UserExport, error) {
+	if err := s.authorize(ctx, auth.PermUserList, 0); err != nil {
+		return nil, err
+	}
+	// Soft-deleted users are only listed to admins
+	if query.IncludeDeleted {
+		if err := s.authorize(ctx, auth.PermUserListDeleted, 0); err != nil {
+			return nil, err
+		}
+	}
+	filter, err := buildUserFilter(query, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	sort, err := parseSort(query.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, fn func(models.UserResponse) error) error {
+		return translateRepoError(s.repo.Export(ctx, filter, sort, func(user db.User) error {
+			return fn(s.toUserResponse(user))
+		}))
+	}, nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/rohanparmar/go-user-api/internal/auth"
+	"github.com/rohanparmar/go-user-api/internal/models"
+)
+
+// GetUserHistory returns the audit trail of a user, newest change first.
+// History outlives the user, so it is available after delete and purge.
+func (s *userService) GetUserHistory(ctx context.Context, id int32, page, limit int) (models.UserHistoryResponse, error) {
+	if err := s.authorize(ctx, auth.PermUserHistory, id); err != nil {
+		return models.UserHistoryResponse{}, err
+	}
+	page, limit = normalizePage(page, limit)
+	offset := (page - 1) * limit
+
+	total, err := s.repo.CountHistory(ctx, id)
+	if err != nil {
+		return models.UserHistoryResponse{}, translateRepoError(err)
+	}
+	if total == 0 {
+		return models.UserHistoryResponse{}, ErrNotFound
+	}
+
+	entries, err := s.repo.History(ctx, id, int32(limit), int32(offset))
+	if err != nil {
+		return models.UserHistoryResponse{}, translateRepoError(err)
+	}
+
+	data := make([]models.UserAuditEntry, 0, len(entries))
+	for _, entry := range entries {
+		auditEntry, err := toAuditEntry(entry)
+		if err != nil {
+			return models.UserHistoryResponse{}, err
+		}
+		data = append(data, auditEntry)
+	}
+
+	return models.UserHistoryResponse{
+		Data:       data,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}, nil
+}
+
+// GetUserAsOf returns the user as it was at the given point in time,
+// reconstructed from the latest audit snapshot at or before asOf.
+func (s *userService) GetUserAsOf(ctx context.Context, id int32, asOf time.Time) (db.User, error) {
+	if err := s.authorize(ctx, auth.PermUserHistory, id); err != nil {
+		return db.User{}, err
+	}
+	entry, err := s.repo.GetAsOf(ctx, id, asOf)
+	if err != nil {
+		return db.User{}, translateRepoError(err)
+	}
+
+	after, err := decodeSnapshot(entry.After)
+	if err != nil {
+		return db.User{}, err
+	}
+	if after == nil || after.DeletedAt != nil {
+		return db.User{}, fmt.Errorf("%w: user %d did not exist at %s", ErrNotFound, id, asOf.Format(time.RFC3339))
+	}
+
+	dob, err := time.Parse("2006-01-02", after.DOB)
+	if err != nil {
+		return db.User{}, fmt.Errorf("decode audit snapshot %d: %w", entry.ID, err)
+	}
+
+	return db.User{
+		ID:        after.ID,
+		Name:      after.Name,
+		Dob:       pgtype.Date{Time: dob, Valid: true},
+		UpdatedAt: entry.ChangedAt,
+		Version:   after.Version,
+	}, nil
+}
+
+// toAuditEntry converts an audit row into its API representation
+func toAuditEntry(entry db.UserAudit) (models.UserAuditEntry, error) {
+	before, err := decodeSnapshot(entry.Before)
+	if err != nil {
+		return models.UserAuditEntry{}, err
+	}
+	after, err := decodeSnapshot(entry.After)
+	if err != nil {
+		return models.UserAuditEntry{}, err
+	}
+
+	return models.UserAuditEntry{
+		ID:        entry.ID,
+		UserID:    entry.UserID,
+		Operation: entry.Operation,
+		Actor:     entry.Actor,
+		RequestID: entry.RequestID,
+		Before:    before,
+		After:     after,
+		ChangedAt: entry.ChangedAt.Time.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// decodeSnapshot decodes a JSONB snapshot (nil for SQL NULL)
+func decodeSnapshot(data []byte) (*models.UserSnapshot, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var snapshot models.UserSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("decode audit snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
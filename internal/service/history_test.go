@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/rohanparmar/go-user-api/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// auditRepo returns a fixed audit entry for point-in-time reads
+type auditRepo struct {
+	repository.UserRepository
+	entry db.UserAudit
+}
+
+func (m *auditRepo) GetAsOf(ctx context.Context, userID int32, asOf time.Time) (db.UserAudit, error) {
+	return m.entry, nil
+}
+
+func TestGetUserAsOf(t *testing.T) {
+	repo := &auditRepo{entry: db.UserAudit{
+		ID:        7,
+		UserID:    1,
+		Operation: "update",
+		After:     []byte(`{"id":1,"name":"Alice","dob":"1990-05-10","version":2}`),
+	}}
+	userService := NewUserService(repo)
+
+	user, err := userService.GetUserAsOf(context.Background(), 1, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", user.Name)
+	assert.Equal(t, "1990-05-10", user.Dob.Time.Format("2006-01-02"))
+	assert.Equal(t, int32(2), user.Version)
+
+	repo.entry.After = []byte(`{"id":1,"name":"Alice","dob":"1990-05-10","version":3,"deleted_at":"2026-01-01T00:00:00Z"}`)
+	_, err = userService.GetUserAsOf(context.Background(), 1, time.Now())
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/rohanparmar/go-user-api/internal/auth"
+	"github.com/rohanparmar/go-user-api/internal/logger"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/repository"
+	"go.uber.org/zap"
+)
+
+// maxImportErrors caps the row errors listed in an import report
+const maxImportErrors = 100
+
+// ImportSource streams the rows of an import file. Rows that could not be
+// parsed carry an Err; Err() reports a failure to read the file itself.
+type ImportSource = repository.ImportSource
+
+// ImportUsers validates users streamed from src with the same rules as
+// CreateUser and bulk inserts them. An import is all-or-nothing: if any row
+// is invalid, or is a duplicate with models.ImportDuplicatesError, nothing is
+// written and the report lists the rejected rows. An import that would take
+// the tenant over its quota fails with a *QuotaError. A dry run reports the
+// same outcome without writing.
+func (s *userService) ImportUsers(ctx context.Context, src ImportSource, opts models.ImportOptions) (models.ImportReport, error) {
+	if err := s.authorize(ctx, auth.PermUserImport, 0); err != nil {
+		return models.ImportReport{}, err
+	}
+	switch opts.Duplicates {
+	case "":
+		opts.Duplicates = models.ImportDuplicatesError
+	case models.ImportDuplicatesError, models.ImportDuplicatesSkip, models.ImportDuplicatesAllow:
+	default:
+		return models.ImportReport{}, NewValidationError("on_duplicate", fmt.Sprintf("must be one of: %s %s %s",
+			models.ImportDuplicatesError, models.ImportDuplicatesSkip, models.ImportDuplicatesAllow))
+	}
+
+	report := &models.ImportReport{DryRun: opts.DryRun, Errors: []models.ImportRowError{}}
+	valid := &validatingSource{src: src, report: report}
+
+	var quotaErr error
+	result, err := s.repo.Import(ctx, valid, opts, func(staged repository.ImportResult) bool {
+		if opts.Duplicates == models.ImportDuplicatesError {
+			for _, line := range staged.Duplicates {
+				addImportError(report, line, NewValidationError("", "duplicates an existing user or an earlier row"))
+			}
+		}
+		if report.Failed == 0 {
+			adding := staged.Staged
+			if opts.Duplicates == models.ImportDuplicatesSkip {
+				adding -= int64(len(staged.Duplicates))
+			}
+			quotaErr = s.checkQuotaCount(ctx, staged.Active, adding)
+		}
+		return !opts.DryRun && report.Failed == 0 && quotaErr == nil
+	})
+	if err != nil {
+		return models.ImportReport{}, translateRepoError(err)
+	}
+	if quotaErr != nil {
+		return models.ImportReport{}, quotaErr
+	}
+
+	slices.SortStableFunc(report.Errors, func(a, b models.ImportRowError) int {
+		return a.Line - b.Line
+	})
+	if opts.Duplicates == models.ImportDuplicatesSkip {
+		report.Skipped = len(result.Duplicates)
+	}
+	switch {
+	case report.Failed > 0:
+		logger.FromContext(ctx).Debug("Import rejected",
+			zap.Int("rows", report.Rows),
+			zap.Int("failed", report.Failed),
+		)
+		report.Imported = 0
+	case opts.DryRun:
+		report.Imported = result.Staged - int64(report.Skipped)
+	default:
+		report.Imported = result.Inserted
+		report.Committed = true
+		s.metrics.UsersCreated.Add(float64(result.Inserted))
+	}
+	return *report, nil
+}
+
+// validatingSource applies the CreateUser rules to rows as they stream past,
+// recording rejected rows in the report and passing on only valid ones
+type validatingSource struct {
+	src    ImportSource
+	report *models.ImportReport
+	row    models.ImportRow
+}
+
+func (v *validatingSource) Next() bool {
+	for v.src.Next() {
+		row := v.src.Row()
+		v.report.Rows++
+		if row.Err == nil {
+			row.Err = validateUser(row.Name, row.DOB)
+		}
+		if row.Err != nil {
+			addImportError(v.report, row.Line, row.Err)
+			continue
+		}
+		v.row = row
+		return true
+	}
+	return false
+}
+
+func (v *validatingSource) Row() models.ImportRow {
+	return v.row
+}
+
+func (v *validatingSource) Err() error {
+	return v.src.Err()
+}
+
+// addImportError records a rejected row, listing at most maxImportErrors
+func addImportError(report *models.ImportReport, line int, err error) {
+	report.Failed++
+	if len(report.Errors) >= maxImportErrors {
+		report.ErrorsTruncated = true
+		return
+	}
+
+	rowErr := models.ImportRowError{Line: line, Message: err.Error()}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		rowErr.Field = validationErr.Field
+	}
+	report.Errors = append(report.Errors, rowErr)
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// sliceSource yields canned import rows
+type sliceSource struct {
+	rows []models.ImportRow
+	i    int
+}
+
+func (s *sliceSource) Next() bool {
+	s.i++
+	return s.i <= len(s.rows)
+}
+
+func (s *sliceSource) Row() models.ImportRow {
+	return s.rows[s.i-1]
+}
+
+func (s *sliceSource) Err() error {
+	return nil
+}
+
+// importRepo stages every row and reports the configured duplicates
+type importRepo struct {
+	repository.UserRepository
+	duplicates []int
+	active     int64
+	staged     []models.ImportRow
+	inserted   bool
+}
+
+func (m *importRepo) Import(ctx context.Context, src repository.ImportSource, opts models.ImportOptions, insert func(repository.ImportResult) bool) (repository.ImportResult, error) {
+	for src.Next() {
+		m.staged = append(m.staged, src.Row())
+	}
+	result := repository.ImportResult{Staged: int64(len(m.staged)), Duplicates: m.duplicates, Active: m.active}
+	if insert(result) {
+		m.inserted = true
+		result.Inserted = result.Staged
+		if opts.Duplicates == models.ImportDuplicatesSkip {
+			result.Inserted -= int64(len(m.duplicates))
+		}
+	}
+	return result, nil
+}
+
+func importRows() *sliceSource {
+	return &sliceSource{rows: []models.ImportRow{
+		{Line: 2, Name: "Alice", DOB: "1990-05-10"},
+		{Line: 3, Name: "Bob", DOB: "1991-02-03"},
+		{Line: 4, Name: "Alice", DOB: "1990-05-10"},
+	}}
+}
+
+func TestImportUsersDuplicates(t *testing.T) {
+	tests := []struct {
+		name       string
+		duplicates string
+		committed  bool
+		imported   int64
+		skipped    int
+		failed     int
+	}{
+		{name: "Error", duplicates: "", committed: false, imported: 0, failed: 1},
+		{name: "Skip", duplicates: models.ImportDuplicatesSkip, committed: true, imported: 2, skipped: 1},
+		{name: "Allow", duplicates: models.ImportDuplicatesAllow, committed: true, imported: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &importRepo{duplicates: []int{4}}
+			if tt.duplicates == models.ImportDuplicatesAllow {
+				repo.duplicates = nil
+			}
+			userService := NewUserService(repo)
+
+			report, err := userService.ImportUsers(context.Background(), importRows(), models.ImportOptions{Duplicates: tt.duplicates})
+			assert.NoError(t, err)
+			assert.Equal(t, 3, report.Rows)
+			assert.Equal(t, tt.committed, report.Committed)
+			assert.Equal(t, tt.committed, repo.inserted)
+			assert.Equal(t, tt.imported, report.Imported)
+			assert.Equal(t, tt.skipped, report.Skipped)
+			assert.Equal(t, tt.failed, report.Failed)
+		})
+	}
+}
+
+func TestImportUsersInvalidRows(t *testing.T) {
+	repo := &importRepo{}
+	userService := NewUserService(repo)
+
+	src := &sliceSource{rows: []models.ImportRow{
+		{Line: 2, Name: "Alice", DOB: "1990-05-10"},
+		{Line: 3, Name: "Bob", DOB: "03/02/1991"},
+		{Line: 4, Err: NewValidationError("name", "is required")},
+	}}
+	report, err := userService.ImportUsers(context.Background(), src, models.ImportOptions{})
+	assert.NoError(t, err)
+
+	// Invalid rows are reported and never staged; nothing is inserted
+	assert.Len(t, repo.staged, 1)
+	assert.False(t, repo.inserted)
+	assert.False(t, report.Committed)
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, []models.ImportRowError{
+		{Line: 3, Field: "dob", Message: "invalid date format, use YYYY-MM-DD"},
+		{Line: 4, Field: "name", Message: "is required"},
+	}, report.Errors)
+}
+
+func TestImportUsersDryRun(t *testing.T) {
+	repo := &importRepo{duplicates: []int{4}}
+	userService := NewUserService(repo)
+
+	report, err := userService.ImportUsers(context.Background(), importRows(), models.ImportOptions{
+		DryRun:     true,
+		Duplicates: models.ImportDuplicatesSkip,
+	})
+	assert.NoError(t, err)
+	assert.False(t, repo.inserted)
+	assert.False(t, report.Committed)
+	assert.Equal(t, int64(2), report.Imported)
+	assert.Equal(t, 1, report.Skipped)
+
+	_, err = userService.ImportUsers(context.Background(), importRows(), models.ImportOptions{Duplicates: "merge"})
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestImportUsersQuota(t *testing.T) {
+	// The quota is checked against the count taken in the import transaction;
+	// importRepo does not implement Count
+	repo := &importRepo{duplicates: []int{4}, active: 1}
+	userService := NewUserService(repo, WithQuotas(3, nil))
+
+	report, err := userService.ImportUsers(context.Background(), importRows(), models.ImportOptions{Duplicates: models.ImportDuplicatesSkip})
+	assert.NoError(t, err)
+	assert.True(t, report.Committed)
+	assert.Equal(t, int64(2), report.Imported)
+
+	repo = &importRepo{duplicates: []int{4}, active: 2}
+	userService = NewUserService(repo, WithQuotas(3, nil))
+	_, err = userService.ImportUsers(context.Background(), importRows(), models.ImportOptions{Duplicates: models.ImportDuplicatesSkip})
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	assert.False(t, repo.inserted)
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"github.com/rohanparmar/go-user-api/internal/auth"
+	"github.com/rohanparmar/go-user-api/internal/metrics"
+)
+
+// Option configures optional userService dependencies
+type Option func(*userService)
+
+// WithCursorSecret sets the key used to sign list cursors. Without it a random
+// per-process key is used, so cursors do not survive restarts or load balancing.
+func WithCursorSecret(secret []byte) Option {
+	return func(s *userService) {
+		s.cursors = newCursorCodec(secret)
+	}
+}
+
+// WithBatchLimit sets the maximum number of operations accepted by BatchUsers
+func WithBatchLimit(limit int) Option {
+	return func(s *userService) {
+		s.batchLimit = limit
+	}
+}
+
+// WithMetrics sets the metrics the service updates. Without it the counters
+// are kept but not registered anywhere.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(s *userService) {
+		s.metrics = m
+	}
+}
+
+// WithQuotas limits the number of active users of each tenant to
+// quotas[tenant], or defaultQuota for tenants not listed. Zero means no limit.
+func WithQuotas(defaultQuota int, quotas map[string]int) Option {
+	return func(s *userService) {
+		s.defaultQuota = defaultQuota
+		s.quotas = quotas
+	}
+}
+
+// WithPolicy makes every method check the caller's permissions with policy
+// (see auth.Policy) before doing anything else. Without it nothing is checked.
+func WithPolicy(policy *auth.Policy) Option {
+	return func(s *userService) {
+		s.policy = policy
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+
+	"github.com/rohanparmar/go-user-api/internal/repository"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+)
+
+// unlimited is returned by quotaRemaining for tenants without a quota
+const unlimited = -1
+
+// quotaFor returns the active user quota of the tenant of ctx, 0 if none
+func (s *userService) quotaFor(ctx context.Context) int64 {
+	if quota, ok := s.quotas[tenant.ID(ctx)]; ok {
+		return int64(quota)
+	}
+	return int64(s.defaultQuota)
+}
+
+// quotaRemaining returns how many more active users the tenant of ctx may
+// have, or unlimited. The count is not locked, so concurrent creates can
+// overshoot a quota by the number of requests in flight.
+func (s *userService) quotaRemaining(ctx context.Context) (int64, error) {
+	quota := s.quotaFor(ctx)
+	if quota <= 0 {
+		return unlimited, nil
+	}
+	count, err := s.repo.Count(ctx, repository.UserFilter{})
+	if err != nil {
+		return 0, translateRepoError(err)
+	}
+	return max(quota-count, 0), nil
+}
+
+// checkQuota returns a *QuotaError unless the tenant of ctx has room for
+// adding more active users
+func (s *userService) checkQuota(ctx context.Context, adding int64) error {
+	remaining, err := s.quotaRemaining(ctx)
+	if err != nil {
+		return err
+	}
+	if remaining != unlimited && adding > remaining {
+		return s.quotaError(ctx)
+	}
+	return nil
+}
+
+// checkQuotaCount is checkQuota for a tenant known to have active users
+func (s *userService) checkQuotaCount(ctx context.Context, active, adding int64) error {
+	if quota := s.quotaFor(ctx); quota > 0 && active+adding > quota {
+		return s.quotaError(ctx)
+	}
+	return nil
+}
+
+func (s *userService) quotaError(ctx context.Context) error {
+	return &QuotaError{Tenant: tenant.ID(ctx), Limit: s.quotaFor(ctx)}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/repository"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+// quotaRepo counts the active users of each tenant
+type quotaRepo struct {
+	batchRepo
+	active map[string]int64
+}
+
+func (m *quotaRepo) Count(ctx context.Context, filter repository.UserFilter) (int64, error) {
+	return m.active[tenant.ID(ctx)], nil
+}
+
+func (m *quotaRepo) Create(ctx context.Context, name string, dob string) (db.User, error) {
+	m.active[tenant.ID(ctx)]++
+	return db.User{Name: name}, nil
+}
+
+func TestQuotas(t *testing.T) {
+	repo := &quotaRepo{active: map[string]int64{"acme": 2, "globex": 2}}
+	userService := NewUserService(repo, WithQuotas(3, map[string]int{"globex": 0}))
+	acme := tenant.WithID(context.Background(), "acme")
+
+	_, err := userService.CreateUser(acme, "Alice", "1990-05-10")
+	assert.NoError(t, err)
+
+	_, err = userService.CreateUser(acme, "Bob", "1990-05-10")
+	var quotaErr *QuotaError
+	assert.ErrorAs(t, err, &quotaErr)
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	assert.Equal(t, QuotaError{Tenant: "acme", Limit: 3}, *quotaErr)
+
+	// A zero quota lifts the default limit for that tenant
+	_, err = userService.CreateUser(tenant.WithID(context.Background(), "globex"), "Bob", "1990-05-10")
+	assert.NoError(t, err)
+
+	// Tenants are counted separately, and only creates beyond the quota fail
+	results, err := userService.BatchUsers(tenant.WithID(context.Background(), "initech"), []models.BatchOperation{
+		{Op: models.BatchOpCreate, Name: "Alice", DOB: "1990-05-10"},
+		{Op: models.BatchOpCreate, Name: "Bob", DOB: "1990-05-10"},
+		{Op: models.BatchOpUpdate, ID: 7, Name: "Carol", DOB: "1985-01-01"},
+		{Op: models.BatchOpCreate, Name: "Carol", DOB: "1990-05-10"},
+		{Op: models.BatchOpCreate, Name: "Dave", DOB: "1990-05-10"},
+	}, false)
+	assert.NoError(t, err)
+	assert.NoError(t, results[0].Err)
+	assert.NoError(t, results[1].Err)
+	assert.NoError(t, results[2].Err)
+	assert.NoError(t, results[3].Err)
+	assert.ErrorIs(t, results[4].Err, ErrQuotaExceeded)
+	assert.Len(t, repo.ops, 4)
+}
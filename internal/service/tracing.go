@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/rohanparmar/go-user-api/internal/auth"
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/tenant"
+	"github.com/rohanparmar/go-user-api/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedUserService wraps a UserService with a span per method call
+type tracedUserService struct {
+	next UserService
+}
+
+// NewTracedUserService returns a UserService that records a span named
+// "UserService.<Method>" around every call to next
+func NewTracedUserService(next UserService) UserService {
+	return &tracedUserService{next: next}
+}
+
+// startSpan starts the span of a method call, tagged with the caller
+func startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if principal, ok := auth.FromContext(ctx); ok {
+		attrs = append(attrs, attribute.String("enduser.id", principal.Subject))
+	}
+	if id, ok := tenant.FromContext(ctx); ok {
+		attrs = append(attrs, attribute.String("tenant.id", id))
+	}
+	return tracing.Tracer().Start(ctx, "UserService."+method, trace.WithAttributes(attrs...))
+}
+
+// endSpan records err on span, if any, and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func userIDAttr(id int32) attribute.KeyValue {
+	return attribute.Int("user.id", int(id))
+}
+
+func (s *tracedUserService) CreateUser(ctx context.Context, name string, dob string) (db.User, error) {
+	ctx, span := startSpan(ctx, "CreateUser")
+	user, err := s.next.CreateUser(ctx, name, dob)
+	endSpan(span, err)
+	return user, err
+}
+
+func (s *tracedUserService) GetUserByID(ctx context.Context, id int32) (db.User, error) {
+	ctx, span := startSpan(ctx, "GetUserByID", userIDAttr(id))
+	user, err := s.next.GetUserByID(ctx, id)
+	endSpan(span, err)
+	return user, err
+}
+
+func (s *tracedUserService) ListUsers(ctx context.Context, query models.ListUsersQuery) (models.UsersListResponse, error) {
+	ctx, span := startSpan(ctx, "ListUsers")
+	response, err := s.next.ListUsers(ctx, query)
+	endSpan(span, err)
+	return response, err
+}
+
+func (s *tracedUserService) UpdateUser(ctx context.Context, id int32, name string, dob string, ifMatch []int32) (db.User, error) {
+	ctx, span := startSpan(ctx, "UpdateUser", userIDAttr(id))
+	user, err := s.next.UpdateUser(ctx, id, name, dob, ifMatch)
+	endSpan(span, err)
+	return user, err
+}
+
+func (s *tracedUserService) PatchUser(ctx context.Context, id int32, patch models.UserPatch) (db.User, error) {
+	ctx, span := startSpan(ctx, "PatchUser", userIDAttr(id))
+	user, err := s.next.PatchUser(ctx, id, patch)
+	endSpan(span, err)
+	return user, err
+}
+
+func (s *tracedUserService) DeleteUser(ctx context.Context, id int32, ifMatch []int32) error {
+	ctx, span := startSpan(ctx, "DeleteUser", userIDAttr(id))
+	err := s.next.DeleteUser(ctx, id, ifMatch)
+	endSpan(span, err)
+	return err
+}
+
+func (s *tracedUserService) RestoreUser(ctx context.Context, id int32) (db.User, error) {
+	ctx, span := startSpan(ctx, "RestoreUser", userIDAttr(id))
+	user, err := s.next.RestoreUser(ctx, id)
+	endSpan(span, err)
+	return user, err
+}
+
+func (s *tracedUserService) PurgeDeletedUsers(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, span := startSpan(ctx, "PurgeDeletedUsers")
+	purged, err := s.next.PurgeDeletedUsers(ctx, retention)
+	span.SetAttributes(attribute.Int64("users.purged", purged))
+	endSpan(span, err)
+	return purged, err
+}
+
+func (s *tracedUserService) BatchUsers(ctx context.Context, ops []models.BatchOperation, atomic bool) ([]BatchResult, error) {
+	ctx, span := startSpan(ctx, "BatchUsers",
+		attribute.Int("batch.size", len(ops)),
+		attribute.Bool("batch.atomic", atomic),
+	)
+	results, err := s.next.BatchUsers(ctx, ops, atomic)
+	endSpan(span, err)
+	return results, err
+}
+
+func (s *tracedUserService) ImportUsers(ctx context.Context, src ImportSource, opts models.ImportOptions) (models.ImportReport, error) {
+	ctx, span := startSpan(ctx, "ImportUsers", attribute.Bool("import.dry_run", opts.DryRun))
+	report, err := s.next.ImportUsers(ctx, src, opts)
+	span.SetAttributes(
+		attribute.Int("import.rows", report.Rows),
+		attribute.Int64("import.imported", report.Imported),
+	)
+	endSpan(span, err)
+	return report, err
+}
+
+func (s *tracedUserService) SearchUsers(ctx context.Context, query string, limit int) (models.UserSearchResponse, error) {
+	ctx, span := startSpan(ctx, "SearchUsers")
+	response, err := s.next.SearchUsers(ctx, query, limit)
+	endSpan(span, err)
+	return response, err
+}
+
+// ExportUsers traces the export itself, which runs after the handler returns
+func (s *tracedUserService) ExportUsers(ctx context.Context, query models.ListUsersQuery) (UserExport, error) {
+	export, err := s.next.ExportUsers(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, fn func(models.UserResponse) error) error {
+		ctx, span := startSpan(ctx, "ExportUsers")
+		err := export(ctx, fn)
+		endSpan(span, err)
+		return err
+	}, nil
+}
+
+func (s *tracedUserService) GetUserHistory(ctx context.Context, id int32, page, limit int) (models.UserHistoryResponse, error) {
+	ctx, span := startSpan(ctx, "GetUserHistory", userIDAttr(id))
+	response, err := s.next.GetUserHistory(ctx, id, page, limit)
+	endSpan(span, err)
+	return response, err
+}
+
+func (s *tracedUserService) GetUserAsOf(ctx context.Context, id int32, asOf time.Time) (db.User, error) {
+	ctx, span := startSpan(ctx, "GetUserAsOf", userIDAttr(id))
+	user, err := s.next.GetUserAsOf(ctx, id, asOf)
+	endSpan(span, err)
+	return user, err
+}
+
+func (s *tracedUserService) CalculateAge(dob time.Time) int {
+	return s.next.CalculateAge(dob)
+}
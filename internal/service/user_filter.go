@@ -0,0 +1,102 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/repository"
+)
+
+// buildUserFilter validates the list filters and converts them into a
+// repository filter. Age bounds are translated into DOB bounds relative to
+// today and intersected with any explicit dob_from/dob_to.
+func buildUserFilter(query models.ListUsersQuery, now time.Time) (repository.UserFilter, error) {
+	filter := repository.UserFilter{
+		NameContains:   strings.TrimSpace(query.NameContains),
+		NamePrefix:     strings.TrimSpace(query.NamePrefix),
+		IncludeDeleted: query.IncludeDeleted,
+	}
+
+	var err error
+	if filter.DOBFrom, err = parseOptionalDate("dob_from", query.DOBFrom); err != nil {
+		return filter, err
+	}
+	if filter.DOBTo, err = parseOptionalDate("dob_to", query.DOBTo); err != nil {
+		return filter, err
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if query.MinAge != nil {
+		if *query.MinAge < 0 {
+			return filter, NewValidationError("min_age", "min_age cannot be negative")
+		}
+		// Turned min_age on or before today
+		latest := today.AddDate(-*query.MinAge, 0, 0)
+		if filter.DOBTo == nil || latest.Before(*filter.DOBTo) {
+			filter.DOBTo = &latest
+		}
+	}
+	if query.MaxAge != nil {
+		if *query.MaxAge < 0 {
+			return filter, NewValidationError("max_age", "max_age cannot be negative")
+		}
+		if query.MinAge != nil && *query.MinAge > *query.MaxAge {
+			return filter, NewValidationError("min_age", "min_age cannot be greater than max_age")
+		}
+		// Not yet turned max_age+1 today
+		earliest := today.AddDate(-(*query.MaxAge + 1), 0, 1)
+		if filter.DOBFrom == nil || earliest.After(*filter.DOBFrom) {
+			filter.DOBFrom = &earliest
+		}
+	}
+
+	if query.CreatedAfter != "" {
+		createdAfter, err := time.Parse(time.RFC3339, query.CreatedAfter)
+		if err != nil {
+			createdAfter, err = time.Parse("2006-01-02", query.CreatedAfter)
+		}
+		if err != nil {
+			return filter, NewValidationError("created_after", "invalid timestamp, use RFC 3339 or YYYY-MM-DD")
+		}
+		filter.CreatedAfter = &createdAfter
+	}
+
+	return filter, nil
+}
+
+// parseSort parses a comma separated list of fields, each optionally prefixed
+// with "-" for descending order, e.g. "name,-dob"
+func parseSort(sort string) ([]repository.SortField, error) {
+	if strings.TrimSpace(sort) == "" {
+		return nil, nil
+	}
+
+	var fields []repository.SortField
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(sort, ",") {
+		part = strings.TrimSpace(part)
+		field := repository.SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if !repository.IsSortable(field.Field) {
+			return nil, NewValidationError("sort", fmt.Sprintf("cannot sort by %q", field.Field))
+		}
+		if seen[field.Field] {
+			return nil, NewValidationError("sort", fmt.Sprintf("%q is listed more than once", field.Field))
+		}
+		seen[field.Field] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func parseOptionalDate(field, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, NewValidationError(field, "invalid date format, use YYYY-MM-DD")
+	}
+	return &t, nil
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rohanparmar/go-user-api/internal/models"
+	"github.com/rohanparmar/go-user-api/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildUserFilterAges(t *testing.T) {
+	now := time.Date(2026, 6, 15, 10, 0, 0, 0, time.UTC)
+	minAge, maxAge := 18, 30
+
+	filter, err := buildUserFilter(models.ListUsersQuery{MinAge: &minAge, MaxAge: &maxAge}, now)
+	assert.NoError(t, err)
+	// 18 today or earlier, and not yet 31
+	assert.Equal(t, "2008-06-15", filter.DOBTo.Format("2006-01-02"))
+	assert.Equal(t, "1995-06-16", filter.DOBFrom.Format("2006-01-02"))
+
+	// Explicit DOB bounds are intersected with the age bounds
+	filter, err = buildUserFilter(models.ListUsersQuery{MinAge: &minAge, DOBTo: "2000-01-01"}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "2000-01-01", filter.DOBTo.Format("2006-01-02"))
+
+	_, err = buildUserFilter(models.ListUsersQuery{MinAge: &maxAge, MaxAge: &minAge}, now)
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = buildUserFilter(models.ListUsersQuery{DOBFrom: "15/06/2000"}, now)
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestParseSort(t *testing.T) {
+	fields, err := parseSort("name,-dob")
+	assert.NoError(t, err)
+	assert.Equal(t, []repository.SortField{{Field: "name"}, {Field: "dob", Desc: true}}, fields)
+
+	_, err = parseSort("name;DROP TABLE users")
+	assert.ErrorIs(t, err, ErrValidation)
+	_, err = parseSort("name,-name")
+	assert.ErrorIs(t, err, ErrValidation)
+}
@@ -5,111 +5,409 @@ Key responsibilities include:
 - Dynamic Age Calculation logic.
 - Pagination calculations.
 - Additional business validation rules.
+- Reporting failures using the error taxonomy defined in errors.go.
 */
 package service
 
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/rohanparmar/go-user-api/internal/auth"
 	"github.com/rohanparmar/go-user-api/internal/repository"
+	"github.com/rohanparmar/go-user-api/internal/metrics"
 	"github.com/rohanparmar/go-user-api/internal/models"
 	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type UserService interface {
 	CreateUser(ctx context.Context, name string, dob string) (db.User, error)
 	GetUserByID(ctx context.Context, id int32) (db.User, error)
-	ListUsers(ctx context.Context, page, limit int) (models.UsersListResponse, error)
-	UpdateUser(ctx context.Context, id int32, name string, dob string) (db.User, error)
-	DeleteUser(ctx context.Context, id int32) error
+	ListUsers(ctx context.Context, query models.ListUsersQuery) (models.UsersListResponse, error)
+	UpdateUser(ctx context.Context, id int32, name string, dob string, ifMatch []int32) (db.User, error)
+	PatchUser(ctx context.Context, id int32, patch models.UserPatch) (db.User, error)
+	DeleteUser(ctx context.Context, id int32, ifMatch []int32) error
+	RestoreUser(ctx context.Context, id int32) (db.User, error)
+	PurgeDeletedUsers(ctx context.Context, retention time.Duration) (int64, error)
+	BatchUsers(ctx context.Context, ops []models.BatchOperation, atomic bool) ([]BatchResult, error)
+	ImportUsers(ctx context.Context, src ImportSource, opts models.ImportOptions) (models.ImportReport, error)
+	SearchUsers(ctx context.Context, query string, limit int) (models.UserSearchResponse, error)
+	ExportUsers(ctx context.Context, query models.ListUsersQuery) (UserExport, error)
+	GetUserHistory(ctx context.Context, id int32, page, limit int) (models.UserHistoryResponse, error)
+	GetUserAsOf(ctx context.Context, id int32, asOf time.Time) (db.User, error)
 	CalculateAge(dob time.Time) int
 }
 
 type userService struct {
-	repo repository.UserRepository
+	repo       repository.UserRepository
+	cursors    *cursorCodec
+	batchLimit int
+	metrics    *metrics.Metrics
+	policy     *auth.Policy
+
+	defaultQuota int
+	quotas       map[string]int
+}
+
+func NewUserService(repo repository.UserRepository, opts ...Option) UserService {
+	s := &userService{repo: repo, batchLimit: DefaultBatchLimit}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.cursors == nil {
+		s.cursors = newCursorCodec(nil)
+	}
+	if s.metrics == nil {
+		s.metrics = metrics.New(prometheus.NewRegistry())
+	}
+	return s
 }
 
-func NewUserService(repo repository.UserRepository) UserService {
-	return &userService{repo: repo}
+// authorize checks perm against the policy, if any. ownerID is the user the
+// operation targets, 0 for operations on no single user.
+func (s *userService) authorize(ctx context.Context, perm auth.Permission, ownerID int32) error {
+	if s.policy == nil {
+		return nil
+	}
+	return s.policy.Authorize(ctx, perm, ownerID)
 }
 
 func (s *userService) CreateUser(ctx context.Context, name string, dob string) (db.User, error) {
-	if name == "" {
-		return db.User{}, errors.New("name cannot be empty")
+	if err := s.authorize(ctx, auth.PermUserCreate, 0); err != nil {
+		return db.User{}, err
 	}
-	if _, err := time.Parse("2006-01-02", dob); err != nil {
-		return db.User{}, errors.New("invalid date format, use YYYY-MM-DD")
+	if err := validateUser(name, dob); err != nil {
+		return db.User{}, err
+	}
+	if err := s.checkQuota(ctx, 1); err != nil {
+		return db.User{}, err
+	}
+	user, err := s.repo.Create(ctx, name, dob)
+	if err != nil {
+		return db.User{}, translateRepoError(err)
 	}
-	return s.repo.Create(ctx, name, dob)
+	s.metrics.UsersCreated.Inc()
+	return user, nil
 }
 
 func (s *userService) GetUserByID(ctx context.Context, id int32) (db.User, error) {
-	return s.repo.GetByID(ctx, id)
+	if err := s.authorize(ctx, auth.PermUserRead, id); err != nil {
+		return db.User{}, err
+	}
+	user, err := s.repo.GetByID(ctx, id)
+	return user, translateRepoError(err)
 }
 
-func (s *userService) ListUsers(ctx context.Context, page, limit int) (models.UsersListResponse, error) {
-	if page < 1 {
-		page = 1
+// ListUsers returns a filtered, sorted page of users. With query.Cursor set it
+// uses keyset pagination (WHERE id > cursor), otherwise LIMIT/OFFSET for
+// compatibility.
+func (s *userService) ListUsers(ctx context.Context, query models.ListUsersQuery) (models.UsersListResponse, error) {
+	if err := s.authorize(ctx, auth.PermUserList, 0); err != nil {
+		return models.UsersListResponse{}, err
 	}
-	if limit < 1 {
-		limit = 10
+	// Soft-deleted users are only listed to admins
+	if query.IncludeDeleted {
+		if err := s.authorize(ctx, auth.PermUserListDeleted, 0); err != nil {
+			return models.UsersListResponse{}, err
+		}
 	}
-	if limit > 100 {
-		limit = 100
+	filter, err := buildUserFilter(query, time.Now())
+	if err != nil {
+		return models.UsersListResponse{}, err
+	}
+	sort, err := parseSort(query.Sort)
+	if err != nil {
+		return models.UsersListResponse{}, err
+	}
+
+	if query.Cursor != nil {
+		if sort != nil {
+			return models.UsersListResponse{}, NewValidationError("sort", "sort is not supported with cursor pagination")
+		}
+		return s.listUsersByCursor(ctx, query, filter)
 	}
 
+	page, limit := normalizePage(query.Page, query.Limit)
 	offset := (page - 1) * limit
 	
 	// Get total count
-	total, err := s.repo.Count(ctx)
+	total, err := s.repo.Count(ctx, filter)
 	if err != nil {
-		return models.UsersListResponse{}, err
+		return models.UsersListResponse{}, translateRepoError(err)
 	}
 	
 	// Get paginated users
-	users, err := s.repo.List(ctx, int32(limit), int32(offset))
+	users, err := s.repo.List(ctx, filter, sort, int32(limit), int32(offset))
 	if err != nil {
-		return models.UsersListResponse{}, err
+		return models.UsersListResponse{}, translateRepoError(err)
 	}
 	
 	// Calculate total pages
 	totalPages := int((total + int64(limit) - 1) / int64(limit))
 
-	// Map to response
-	var responseData []models.UserResponse
-	for _, user := range users {
-		age := s.CalculateAge(user.Dob.Time)
-		responseData = append(responseData, models.UserResponse{
-			ID:   user.ID,
-			Name: user.Name,
-			DOB:  user.Dob.Time.Format("2006-01-02"),
-			Age:  &age,
-		})
-	}
-	
 	return models.UsersListResponse{
-		Data:       responseData,
-		Total:      total,
+		Data:       s.toUserResponses(users),
+		Total:      &total,
 		Page:       page,
 		Limit:      limit,
-		TotalPages: totalPages,
+		TotalPages: &totalPages,
 	}, nil
 }
 
-func (s *userService) UpdateUser(ctx context.Context, id int32, name string, dob string) (db.User, error) {
-	if name == "" {
-		return db.User{}, errors.New("name cannot be empty")
+// listUsersByCursor serves ListUsers in cursor mode. An empty cursor starts
+// from the beginning; the total is only counted when requested.
+func (s *userService) listUsersByCursor(ctx context.Context, query models.ListUsersQuery, filter repository.UserFilter) (models.UsersListResponse, error) {
+	_, limit := normalizePage(1, query.Limit)
+
+	state := cursorState{Filter: filterFingerprint(ctx, filter)}
+	if *query.Cursor != "" {
+		decoded, err := s.cursors.decode(*query.Cursor)
+		if err != nil {
+			return models.UsersListResponse{}, err
+		}
+		if decoded.Filter != state.Filter {
+			return models.UsersListResponse{}, NewValidationError("cursor", "cursor was issued for a different query")
+		}
+		state = decoded
 	}
-	if _, err := time.Parse("2006-01-02", dob); err != nil {
-		return db.User{}, errors.New("invalid date format, use YYYY-MM-DD")
+
+	// Fetch one extra row to know whether there is a next page
+	pageFilter := filter
+	pageFilter.AfterID = state.AfterID
+	users, err := s.repo.List(ctx, pageFilter, nil, int32(limit+1), 0)
+	if err != nil {
+		return models.UsersListResponse{}, translateRepoError(err)
+	}
+
+	response := models.UsersListResponse{Limit: limit}
+	if len(users) > limit {
+		users = users[:limit]
+		next := state
+		next.AfterID = users[len(users)-1].ID
+		response.NextCursor = s.cursors.encode(next)
+	}
+	response.Data = s.toUserResponses(users)
+
+	if query.IncludeTotal {
+		total, err := s.repo.Count(ctx, filter)
+		if err != nil {
+			return models.UsersListResponse{}, translateRepoError(err)
+		}
+		response.Total = &total
+	}
+
+	return response, nil
+}
+
+// SearchUsers finds users whose name approximately matches query, best match first
+func (s *userService) SearchUsers(ctx context.Context, query string, limit int) (models.UserSearchResponse, error) {
+	if err := s.authorize(ctx, auth.PermUserList, 0); err != nil {
+		return models.UserSearchResponse{}, err
+	}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return models.UserSearchResponse{}, NewValidationError("q", "search query cannot be empty")
+	}
+	if len(query) > 100 {
+		return models.UserSearchResponse{}, NewValidationError("q", "search query cannot be longer than 100 characters")
+	}
+	_, limit = normalizePage(1, limit)
+
+	rows, err := s.repo.Search(ctx, query, int32(limit))
+	if err != nil {
+		return models.UserSearchResponse{}, translateRepoError(err)
 	}
-	return s.repo.Update(ctx, id, name, dob)
+
+	results := make([]models.UserSearchResult, 0, len(rows))
+	for _, row := range rows {
+		age := s.CalculateAge(row.Dob.Time)
+		results = append(results, models.UserSearchResult{
+			UserResponse: models.UserResponse{
+				ID:   row.ID,
+				Name: row.Name,
+				DOB:  row.Dob.Time.Format("2006-01-02"),
+				Age:  &age,
+			},
+			Score: row.Score,
+		})
+	}
+
+	return models.UserSearchResponse{
+		Query: query,
+		Data:  results,
+		Limit: limit,
+	}, nil
 }
 
-func (s *userService) DeleteUser(ctx context.Context, id int32) error {
-	return s.repo.Delete(ctx, id)
+// toUserResponses maps users to list responses with their age
+func (s *userService) toUserResponses(users []db.User) []models.UserResponse {
+	var responseData []models.UserResponse
+	for _, user := range users {
+		responseData = append(responseData, s.toUserResponse(user))
+	}
+	return responseData
+}
+
+// toUserResponse maps a user to a response with its age
+func (s *userService) toUserResponse(user db.User) models.UserResponse {
+	age := s.CalculateAge(user.Dob.Time)
+	response := models.UserResponse{
+		ID:   user.ID,
+		Name: user.Name,
+		DOB:  user.Dob.Time.Format("2006-01-02"),
+		Age:  &age,
+	}
+	if user.DeletedAt.Valid {
+		deletedAt := user.DeletedAt.Time.UTC().Format(time.RFC3339)
+		response.DeletedAt = &deletedAt
+	}
+	return response
+}
+
+// UpdateUser replaces a user. When ifMatch is non-nil the update only applies
+// if the stored version is one of ifMatch, otherwise ErrPreconditionFailed.
+func (s *userService) UpdateUser(ctx context.Context, id int32, name string, dob string, ifMatch []int32) (db.User, error) {
+	if err := s.authorize(ctx, auth.PermUserUpdate, id); err != nil {
+		return db.User{}, err
+	}
+	if err := validateUser(name, dob); err != nil {
+		return db.User{}, err
+	}
+	user, err := s.repo.Update(ctx, id, name, dob, ifMatch)
+	if errors.Is(err, ErrNotFound) && ifMatch != nil {
+		return db.User{}, s.explainNoMatch(ctx, id, ifMatch, false)
+	}
+	if err != nil {
+		return db.User{}, translateRepoError(err)
+	}
+	s.metrics.UsersUpdated.Inc()
+	return user, nil
+}
+
+// PatchUser applies a partial update. Only the supplied fields are written,
+// and any test preconditions are checked atomically by the update query.
+func (s *userService) PatchUser(ctx context.Context, id int32, patch models.UserPatch) (db.User, error) {
+	if err := s.authorize(ctx, auth.PermUserUpdate, id); err != nil {
+		return db.User{}, err
+	}
+	if patch.Name != nil && *patch.Name == "" {
+		return db.User{}, NewValidationError("name", "name cannot be empty")
+	}
+	for _, dob := range []*string{patch.DOB, patch.TestDOB} {
+		if dob == nil {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", *dob); err != nil {
+			return db.User{}, NewValidationError("dob", "invalid date format, use YYYY-MM-DD")
+		}
+	}
+
+	user, err := s.repo.Patch(ctx, id, patch)
+	hasTests := patch.TestName != nil || patch.TestDOB != nil
+	if errors.Is(err, ErrNotFound) && (hasTests || patch.IfMatch != nil) {
+		return db.User{}, s.explainNoMatch(ctx, id, patch.IfMatch, hasTests)
+	}
+	if err != nil {
+		return db.User{}, translateRepoError(err)
+	}
+	s.metrics.UsersUpdated.Inc()
+	return user, nil
+}
+
+// DeleteUser removes a user, honouring ifMatch like UpdateUser
+func (s *userService) DeleteUser(ctx context.Context, id int32, ifMatch []int32) error {
+	if err := s.authorize(ctx, auth.PermUserDelete, id); err != nil {
+		return err
+	}
+	err := s.repo.Delete(ctx, id, ifMatch)
+	if errors.Is(err, ErrNotFound) && ifMatch != nil {
+		return s.explainNoMatch(ctx, id, ifMatch, false)
+	}
+	if err != nil {
+		return translateRepoError(err)
+	}
+	s.metrics.UsersDeleted.Inc()
+	return nil
+}
+
+// RestoreUser undoes a soft delete
+func (s *userService) RestoreUser(ctx context.Context, id int32) (db.User, error) {
+	if err := s.authorize(ctx, auth.PermUserRestore, id); err != nil {
+		return db.User{}, err
+	}
+	if err := s.checkQuota(ctx, 1); err != nil {
+		return db.User{}, err
+	}
+	user, err := s.repo.Restore(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		if _, getErr := s.repo.GetByID(ctx, id); getErr == nil {
+			return db.User{}, fmt.Errorf("%w: user %d is not deleted", ErrConflict, id)
+		}
+	}
+	if err != nil {
+		return db.User{}, translateRepoError(err)
+	}
+	s.metrics.UsersRestored.Inc()
+	return user, nil
+}
+
+// PurgeDeletedUsers permanently removes users soft-deleted more than retention ago
+func (s *userService) PurgeDeletedUsers(ctx context.Context, retention time.Duration) (int64, error) {
+	if err := s.authorize(ctx, auth.PermUserPurge, 0); err != nil {
+		return 0, err
+	}
+	if retention < 0 {
+		return 0, NewValidationError("retention", "retention cannot be negative")
+	}
+	purged, err := s.repo.Purge(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, translateRepoError(err)
+	}
+	s.metrics.UsersPurged.Add(float64(purged))
+	return purged, nil
+}
+
+// explainNoMatch is called when a conditional write matched no row. It tells
+// a missing user apart from a stale If-Match version or a failed patch test.
+func (s *userService) explainNoMatch(ctx context.Context, id int32, ifMatch []int32, hasTests bool) error {
+	current, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return translateRepoError(err)
+	}
+	if hasTests && (ifMatch == nil || slices.Contains(ifMatch, current.Version)) {
+		return fmt.Errorf("%w: patch test operation failed", ErrConflict)
+	}
+	return fmt.Errorf("%w: user %d is at version %d", ErrPreconditionFailed, id, current.Version)
+}
+
+// normalizePage clamps pagination parameters to their allowed ranges
+func normalizePage(page, limit int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return page, limit
+}
+
+// validateUser applies the business rules shared by create and update
+func validateUser(name string, dob string) error {
+	if name == "" {
+		return NewValidationError("name", "name cannot be empty")
+	}
+	if _, err := time.Parse("2006-01-02", dob); err != nil {
+		return NewValidationError("dob", "invalid date format, use YYYY-MM-DD")
+	}
+	return nil
 }
 
 // CalculateAge calculates the age from date of birth
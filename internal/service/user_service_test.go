@@ -2,11 +2,17 @@ package service
 
 import (
 	"context"
+	"slices"
 	"testing"
 	"time"
 
+	"github.com/rohanparmar/go-user-api/internal/auth"
+	"github.com/rohanparmar/go-user-api/internal/metrics"
+	"github.com/rohanparmar/go-user-api/internal/models"
 	"github.com/rohanparmar/go-user-api/internal/repository"
 	db "github.com/rohanparmar/go-user-api/db/sqlc/generated"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -84,3 +90,159 @@ func calculateExpectedAge(dob time.Time) int {
 	}
 	return age
 }
+
+
+func TestCreateUserValidation(t *testing.T) {
+	userService := NewUserService(&mockRepo{})
+
+	tests := []struct {
+		name  string
+		input string
+		dob   string
+		field string
+	}{
+		{name: "Empty name", input: "", dob: "1990-01-01", field: "name"},
+		{name: "Invalid date", input: "Alice", dob: "01/01/1990", field: "dob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := userService.CreateUser(context.Background(), tt.input, tt.dob)
+			assert.ErrorIs(t, err, ErrValidation)
+
+			var validationErr *ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.field, validationErr.Field)
+		})
+	}
+}
+
+// conditionalRepo simulates a stored user at a fixed version
+type conditionalRepo struct {
+	repository.UserRepository
+	version int32
+}
+
+func (m *conditionalRepo) GetByID(ctx context.Context, id int32) (db.User, error) {
+	return db.User{ID: id, Version: m.version}, nil
+}
+
+func (m *conditionalRepo) Delete(ctx context.Context, id int32, ifMatch []int32) error {
+	if ifMatch != nil && !slices.Contains(ifMatch, m.version) {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func TestDeleteUserIfMatch(t *testing.T) {
+	userService := NewUserService(&conditionalRepo{version: 3})
+
+	assert.NoError(t, userService.DeleteUser(context.Background(), 1, []int32{3}))
+	assert.NoError(t, userService.DeleteUser(context.Background(), 1, nil))
+	assert.ErrorIs(t, userService.DeleteUser(context.Background(), 1, []int32{2}), ErrPreconditionFailed)
+}
+
+func TestDomainMetrics(t *testing.T) {
+	m := metrics.New(prometheus.NewRegistry())
+	userService := NewUserService(&conditionalRepo{version: 3}, WithMetrics(m))
+
+	assert.NoError(t, userService.DeleteUser(context.Background(), 1, []int32{3}))
+	assert.Error(t, userService.DeleteUser(context.Background(), 1, []int32{2}))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.UsersDeleted))
+
+	purgeService := NewUserService(&purgeRepo{}, WithMetrics(m))
+	_, err := purgeService.PurgeDeletedUsers(context.Background(), time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.UsersPurged))
+}
+
+// purgeRepo records the cutoff passed to Purge
+type purgeRepo struct {
+	repository.UserRepository
+	cutoff time.Time
+}
+
+func (m *purgeRepo) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	m.cutoff = deletedBefore
+	return 2, nil
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	repo := &purgeRepo{}
+	userService := NewUserService(repo)
+
+	purged, err := userService.PurgeDeletedUsers(context.Background(), 24*time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+	assert.WithinDuration(t, time.Now().Add(-24*time.Hour), repo.cutoff, time.Minute)
+
+	_, err = userService.PurgeDeletedUsers(context.Background(), -time.Hour)
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+// searchRepo returns canned search results
+type searchRepo struct {
+	repository.UserRepository
+	query string
+}
+
+func (m *searchRepo) Search(ctx context.Context, query string, limit int32) ([]db.SearchUsersRow, error) {
+	m.query = query
+	return []db.SearchUsersRow{
+		{ID: 1, Name: "Alice", Score: 0.8},
+		{ID: 2, Name: "Alicia", Score: 0.5},
+	}, nil
+}
+
+func TestSearchUsers(t *testing.T) {
+	repo := &searchRepo{}
+	userService := NewUserService(repo)
+
+	response, err := userService.SearchUsers(context.Background(), "  alice ", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", repo.query)
+	assert.Equal(t, 10, response.Limit)
+	assert.Len(t, response.Data, 2)
+	assert.Equal(t, "Alice", response.Data[0].Name)
+	assert.Equal(t, float32(0.8), response.Data[0].Score)
+
+	_, err = userService.SearchUsers(context.Background(), " ", 10)
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestPolicyIsEnforced(t *testing.T) {
+	policy, err := auth.NewPolicy(nil)
+	assert.NoError(t, err)
+	userService := NewUserService(&conditionalRepo{version: 3}, WithPolicy(policy))
+
+	owner := auth.WithPrincipal(context.Background(), auth.Principal{Subject: "bob", Roles: []string{"user"}, UserID: 7})
+	_, err = userService.GetUserByID(owner, 7)
+	assert.NoError(t, err)
+
+	_, err = userService.GetUserByID(owner, 8)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	err = userService.DeleteUser(owner, 7, nil)
+	var permErr *PermissionError
+	assert.ErrorAs(t, err, &permErr)
+	assert.Equal(t, auth.PermUserDelete, permErr.Permission)
+
+	// Batches are checked per operation
+	results, err := userService.BatchUsers(owner, []models.BatchOperation{
+		{Op: models.BatchOpDelete, ID: 7},
+	}, false)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, results[0].Err, ErrForbidden)
+
+	// Soft-deleted users are not listed to read-only callers
+	for _, role := range []string{"users:read", "support"} {
+		reader := auth.WithPrincipal(context.Background(), auth.Principal{Subject: "carol", Roles: []string{role}})
+		_, err = userService.ListUsers(reader, models.ListUsersQuery{IncludeDeleted: true})
+		assert.ErrorAs(t, err, &permErr)
+		assert.Equal(t, auth.PermUserListDeleted, permErr.Permission)
+
+		_, err = userService.ExportUsers(reader, models.ListUsersQuery{IncludeDeleted: true})
+		assert.ErrorAs(t, err, &permErr)
+		assert.Equal(t, auth.PermUserListDeleted, permErr.Permission)
+	}
+}
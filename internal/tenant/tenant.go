@@ -0,0 +1,49 @@
+/*
+Package tenant identifies the customer organisation a request acts for.
+Middleware resolves the tenant from the caller's credentials or a request
+header and stores it in the request context; the repository reads it back to
+scope every query on users and user_audit.
+*/
+package tenant
+
+import (
+	"context"
+	"regexp"
+)
+
+// Default is the tenant of requests that do not name one, and the tenant
+// rows created before multi-tenancy were assigned to
+const Default = "default"
+
+// All stands for every tenant. It is never a valid tenant ID; only jobs
+// started by the server itself, such as the purge, use it.
+const All = "*"
+
+// idPattern restricts tenant IDs to short, URL and log friendly strings
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,62}$`)
+
+// Valid reports whether id is an acceptable tenant ID
+func Valid(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+type contextKey struct{}
+
+// WithID returns a copy of ctx acting for tenant id
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant stored in ctx, if any
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}
+
+// ID returns the tenant stored in ctx, or Default
+func ID(ctx context.Context) string {
+	if id, ok := FromContext(ctx); ok {
+		return id
+	}
+	return Default
+}
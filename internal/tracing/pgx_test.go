@@ -0,0 +1,13 @@
+package tracing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryName(t *testing.T) {
+	assert.Equal(t, "GetUserByID", queryName("-- name: GetUserByID :one\nSELECT id FROM users WHERE id = $1"))
+	assert.Equal(t, "DECLARE", queryName("  declare user_export NO SCROLL CURSOR FOR SELECT 1"))
+	assert.Equal(t, "query", queryName(""))
+}